@@ -8,19 +8,46 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/jordan-simonovski/helmver/internal/changelog"
-	"github.com/jordan-simonovski/helmver/internal/changeset"
+	"github.com/jordan-simonovski/helmver/internal/apply"
 	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/clock"
+	"github.com/jordan-simonovski/helmver/internal/config"
+	"github.com/jordan-simonovski/helmver/internal/git"
 )
 
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply pending changeset files",
-	Long:  "Reads all .helmver/*.md changeset files, computes version bumps (highest bump wins per chart), applies them to Chart.yaml, writes changelogs, and removes consumed changeset files.",
+	Long:  "Reads all .helmver/*.md changeset files, computes version bumps (highest bump wins per chart), applies them to Chart.yaml, writes changelogs, and removes consumed changeset files. Charts that depend on a bumped chart through a file:// repository get their dependency pin updated and at least a patch bump. All changes are planned before anything is written; if any write fails, every file is restored. Use --dry-run to preview the changes as unified diffs. --commit commits exactly the files apply changed, and --tag adds an annotated tag per released chart with its changelog entry as the message. A new version that already has a CHANGELOG entry, or already appears in the chart's git history, is refused unless --merge is set, in which case the notes are added to the existing entry.",
 	RunE:  runApply,
 }
 
+var (
+	applyDryRun bool
+	applyFormat string
+	applyMerge  bool
+	applyCommit bool
+	applyTag    bool
+)
+
+func init() {
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "print the planned changes as unified diffs without writing anything")
+	applyCmd.Flags().StringVar(&applyFormat, "format", "text", "output format: text or json")
+	applyCmd.Flags().BoolVar(&applyMerge, "merge", false, "add notes to an existing CHANGELOG entry when the new version already has one")
+	applyCmd.Flags().BoolVar(&applyCommit, "commit", false, "commit the files apply changed, with the commit.message template from config")
+	applyCmd.Flags().BoolVar(&applyTag, "tag", false, "with --commit, tag each released chart using --tag-pattern (default {name}-{version})")
+	applyCmd.Flags().StringVar(&date, "date", "", "release date for changelog entries (YYYY-MM-DD or RFC 3339); defaults to SOURCE_DATE_EPOCH, then today")
+	applyCmd.Flags().StringVar(&preID, "preid", chart.DefaultPreID, "pre-release identifier for premajor, preminor, prepatch and prerelease bumps")
+}
+
 func runApply(cmd *cobra.Command, args []string) error {
+	if applyTag && !applyCommit {
+		return fmt.Errorf("--tag requires --commit")
+	}
+	if err := chart.ValidatePreID(preID); err != nil {
+		return err
+	}
+
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		return err
@@ -31,66 +58,181 @@ func runApply(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	files, err := changeset.Discover(cwd)
+	cfg, err := config.Load(cwd)
 	if err != nil {
-		return fmt.Errorf("reading changesets: %w", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		fmt.Println("no pending changesets")
-		return nil
+	clk, err := newClock(cfg)
+	if err != nil {
+		return err
 	}
 
-	resolved := changeset.Aggregate(files)
-
-	chartPaths, err := chart.Discover(absDir, exclude)
+	plan, err := apply.Build(apply.Options{
+		Dir:     absDir,
+		Root:    cwd,
+		Exclude: exclude,
+		PreID:   preID,
+		Config:  cfg,
+		Merge:   applyMerge,
+		Clock:   clk,
+	})
 	if err != nil {
-		return fmt.Errorf("discovering charts: %w", err)
+		return err
+	}
+	if applyFormat != "text" && applyFormat != "json" {
+		return fmt.Errorf("unknown format %q (use text or json)", applyFormat)
+	}
+	if plan == nil && applyFormat == "text" {
+		fmt.Println("no pending changesets")
+		return nil
 	}
 
-	chartsByName := make(map[string]*chart.Chart)
-	for _, p := range chartPaths {
-		c, err := chart.Load(p)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
-			continue
+	if plan != nil {
+		for _, w := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
 		}
-		chartsByName[c.Name] = c
 	}
 
-	var applied int
-	for name, r := range resolved {
-		c, ok := chartsByName[name]
-		if !ok {
-			fmt.Fprintf(os.Stderr, "warning: changeset references chart %q but no Chart.yaml found\n", name)
-			continue
+	var commit *releaseCommit
+	if plan != nil && applyCommit {
+		if commit, err = newReleaseCommit(plan, cwd, cfg); err != nil {
+			return err
 		}
+	}
 
-		newVer, err := chart.BumpVersion(c.Version, r.Bump)
+	if applyDryRun || applyFormat == "json" {
+		if !applyDryRun && plan != nil {
+			if err := plan.Execute(); err != nil {
+				return fmt.Errorf("applying changesets: %w", err)
+			}
+			if err := commit.create(plan); err != nil {
+				return err
+			}
+		}
+		out, err := apply.Format(plan, applyFormat, cwd, applyDryRun)
 		if err != nil {
-			return fmt.Errorf("bumping %s: %w", name, err)
+			return err
+		}
+		fmt.Print(out)
+		if applyDryRun && applyFormat == "text" {
+			commit.preview()
+			fmt.Printf("\ndry run: %d chart(s) would be updated, %d changeset(s) would be consumed\n", len(plan.Releases), len(plan.Consumed))
 		}
+		return nil
+	}
+
+	if err := plan.Execute(); err != nil {
+		return fmt.Errorf("applying changesets: %w", err)
+	}
 
-		oldVer := c.Version
-		if err := c.SetVersion(newVer); err != nil {
-			return fmt.Errorf("updating %s: %w", c.Path, err)
+	for _, rel := range plan.Releases {
+		appNote := ""
+		if rel.NewAppVersion != "" {
+			appNote = fmt.Sprintf(", appVersion %s -> %s", apply.DisplayVersion(rel.OldAppVersion), rel.NewAppVersion)
 		}
+		fmt.Printf("  %s: %s -> %s (%s)%s\n", rel.Label, rel.OldVersion, rel.NewVersion, rel.Bump, appNote)
+	}
+	for _, path := range plan.Kept {
+		fmt.Fprintf(os.Stderr, "warning: skipped %s: it references charts that were not found; none of its entries were applied\n", filepath.Base(path))
+	}
 
-		message := strings.Join(r.Messages, "\n\n")
-		if err := changelog.Prepend(c.Dir, newVer, message); err != nil {
-			return fmt.Errorf("updating changelog for %s: %w", name, err)
+	fmt.Printf("\n%d chart(s) updated, %d changeset(s) consumed\n", len(plan.Releases), len(plan.Consumed))
+	if err := commit.create(plan); err != nil {
+		return err
+	}
+	if commit != nil {
+		subject, _, _ := strings.Cut(commit.message, "\n")
+		fmt.Printf("committed %q\n", subject)
+		for _, t := range commit.tags {
+			fmt.Printf("tagged %s\n", t.Name)
 		}
+	}
+	return nil
+}
 
-		fmt.Printf("  %s: %s -> %s (%s)\n", name, oldVer, newVer, r.Bump)
-		applied++
+// releaseCommit is the commit and tags apply --commit makes after writing
+// the plan.
+type releaseCommit struct {
+	repoRoot string
+	message  string
+	tags     []apply.Tag
+}
+
+// newReleaseCommit prepares the release commit and tags, and refuses tags that
+// already exist before anything is written.
+func newReleaseCommit(plan *apply.Plan, cwd string, cfg *config.Config) (*releaseCommit, error) {
+	repoRoot, err := git.RepoRoot(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("--commit: %w", err)
+	}
+	msg, err := plan.CommitMessage(cfg.Commit.Message)
+	if err != nil {
+		return nil, err
+	}
+	r := &releaseCommit{repoRoot: repoRoot, message: msg}
+	if !applyTag {
+		return r, nil
 	}
 
-	for _, f := range files {
-		if err := changeset.Remove(f.Path); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: removing %s: %s\n", f.Path, err)
+	pattern := tagPattern
+	if pattern == "" {
+		pattern = cfg.Staleness.TagPattern
+	}
+	if pattern == "" {
+		pattern = apply.DefaultTagPattern
+	}
+	p, err := git.ParseTagPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if r.tags, err = plan.Tags(p); err != nil {
+		return nil, err
+	}
+	for _, t := range r.tags {
+		if git.TagExists(repoRoot, t.Name) {
+			return nil, fmt.Errorf("tag %s already exists", t.Name)
 		}
 	}
+	return r, nil
+}
 
-	fmt.Printf("\n%d chart(s) updated, %d changeset(s) consumed\n", applied, len(files))
+// create commits the plan's files and creates the tags. A nil
+// releaseCommit does nothing.
+func (r *releaseCommit) create(plan *apply.Plan) error {
+	if r == nil {
+		return nil
+	}
+	if err := git.Commit(r.repoRoot, plan.Paths(), r.message); err != nil {
+		return fmt.Errorf("committing release: %w", err)
+	}
+	for _, t := range r.tags {
+		if err := git.CreateTag(r.repoRoot, t.Name, t.Message); err != nil {
+			return fmt.Errorf("tagging %s: %w", t.Release.Label, err)
+		}
+	}
 	return nil
 }
+
+// preview prints the commit message and tags for --dry-run.
+func (r *releaseCommit) preview() {
+	if r == nil {
+		return
+	}
+	fmt.Printf("\ncommit message:\n")
+	for _, line := range strings.Split(strings.TrimRight(r.message, "\n"), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	if len(r.tags) > 0 {
+		fmt.Printf("\ntags:\n")
+		for _, t := range r.tags {
+			fmt.Printf("  %s\n", t.Name)
+		}
+	}
+}
+
+// newClock returns the release date source for --date, SOURCE_DATE_EPOCH
+// and the date settings in cfg.
+func newClock(cfg *config.Config) (*clock.Clock, error) {
+	return clock.New(date, cfg.Date.Timezone, cfg.Date.Layout)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jordan-simonovski/helmver/internal/changelog"
+	"github.com/jordan-simonovski/helmver/internal/chart"
+)
+
+var (
+	showVersion string
+	showFormat  string
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Read chart changelogs",
+}
+
+var changelogShowCmd = &cobra.Command{
+	Use:   "show <chart>",
+	Short: "Print the release notes for one chart version",
+	Long:  "Parses the chart's CHANGELOG.md and prints the notes for one release (the newest by default) as markdown or JSON. The chart is referenced by name or by path.",
+	Example: `  helmver changelog show api
+  helmver changelog show charts/api --version 1.3.0 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runChangelogShow,
+}
+
+func init() {
+	changelogShowCmd.Flags().StringVar(&showVersion, "version", "", "release version to show (default: the newest)")
+	changelogShowCmd.Flags().StringVar(&showFormat, "format", "markdown", "output format: markdown or json")
+	changelogCmd.AddCommand(changelogShowCmd)
+}
+
+func runChangelogShow(cmd *cobra.Command, args []string) error {
+	if showFormat != "markdown" && showFormat != "json" {
+		return fmt.Errorf("unknown format %q (use markdown or json)", showFormat)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	chartPaths, err := chart.Discover(absDir, exclude)
+	if err != nil {
+		return fmt.Errorf("discovering charts: %w", err)
+	}
+	var all []*chart.Chart
+	for _, p := range chartPaths {
+		c, err := chart.Load(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			continue
+		}
+		all = append(all, c)
+	}
+	idx := chart.NewIndex(cwd, all)
+
+	c, err := idx.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	doc, err := changelog.ParseFile(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s has no CHANGELOG.md", idx.Label(c))
+	}
+	if err != nil {
+		return err
+	}
+	rel, err := doc.Find(showVersion)
+	if err != nil {
+		return fmt.Errorf("%s: %w", idx.Label(c), err)
+	}
+
+	if showFormat == "markdown" {
+		fmt.Println(rel.Body)
+		return nil
+	}
+
+	type section struct {
+		Title string   `json:"title"`
+		Type  string   `json:"type,omitempty"`
+		Items []string `json:"items"`
+		Body  string   `json:"body"`
+	}
+	payload := struct {
+		Chart    string    `json:"chart"`
+		ID       string    `json:"id"`
+		Version  string    `json:"version"`
+		Date     string    `json:"date,omitempty"`
+		Body     string    `json:"body"`
+		Notes    string    `json:"notes,omitempty"`
+		Sections []section `json:"sections"`
+	}{
+		Chart:    c.Name,
+		ID:       c.ID,
+		Version:  rel.Version,
+		Date:     rel.Date,
+		Body:     rel.Body,
+		Notes:    rel.Notes,
+		Sections: []section{},
+	}
+	for _, s := range rel.Sections {
+		items := s.Items
+		if items == nil {
+			items = []string{}
+		}
+		payload.Sections = append(payload.Sections, section{Title: s.Title, Type: s.Type, Items: items, Body: s.Body})
+	}
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -7,9 +7,13 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/jordan-simonovski/helmver/internal/apply"
 	"github.com/jordan-simonovski/helmver/internal/changelog"
 	"github.com/jordan-simonovski/helmver/internal/changeset"
 	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/check"
+	"github.com/jordan-simonovski/helmver/internal/clock"
+	"github.com/jordan-simonovski/helmver/internal/config"
 	"github.com/jordan-simonovski/helmver/internal/git"
 	"github.com/jordan-simonovski/helmver/internal/tui"
 )
@@ -25,9 +29,15 @@ var changesetCmd = &cobra.Command{
 
 func init() {
 	changesetCmd.Flags().BoolVar(&writeChangesetFlag, "write", false, "write .helmver/ changeset files instead of applying immediately")
+	changesetCmd.Flags().StringVar(&date, "date", "", "release date for changelog entries (YYYY-MM-DD or RFC 3339); defaults to SOURCE_DATE_EPOCH, then today")
+	changesetCmd.Flags().StringVar(&preID, "preid", chart.DefaultPreID, "pre-release identifier for premajor, preminor, prepatch and prerelease bumps")
 }
 
 func runChangeset(cmd *cobra.Command, args []string) error {
+	if err := chart.ValidatePreID(preID); err != nil {
+		return err
+	}
+
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		return err
@@ -64,16 +74,6 @@ func runChangeset(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
 			continue
 		}
-
-		if hasGit {
-			isStale, err := git.IsStale(repoRoot, c.Dir, c.Path, baseRef, c.Version)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "warning: checking %s: %s\n", c.Name, err)
-			} else {
-				c.Stale = isStale
-			}
-		}
-
 		all = append(all, c)
 	}
 
@@ -82,7 +82,55 @@ func runChangeset(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	changesets, err := tui.Run(all)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// Load the config before the TUI so a broken changelog template or date
+	// setting fails before any input is collected.
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return err
+	}
+	if _, err := changelog.NewRenderer(cfg.Changelog.Template, cfg.Changelog.Header); err != nil {
+		return err
+	}
+	clk, err := newClock(cfg)
+	if err != nil {
+		return err
+	}
+
+	idx := chart.NewIndex(cwd, all)
+
+	if hasGit {
+		refs, err := check.ChartRefs(idx, all, cfg.Staleness)
+		if err != nil {
+			return err
+		}
+		pattern := tagPattern
+		if pattern == "" {
+			pattern = cfg.Staleness.TagPattern
+		}
+		if pattern != "" {
+			p, err := git.ParseTagPattern(pattern)
+			if err != nil {
+				return err
+			}
+			if _, err := check.ReleaseTags(repoRoot, p, all, refs); err != nil {
+				return err
+			}
+		}
+		stale, err := git.StaleCharts(repoRoot, baseRef, git.Committed, refs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: checking staleness: %s\n", err)
+		} else {
+			for i, c := range all {
+				c.Stale = stale[i].Stale
+			}
+		}
+	}
+
+	changesets, err := tui.Run(all, preID)
 	if err != nil {
 		return err
 	}
@@ -93,42 +141,58 @@ func runChangeset(cmd *cobra.Command, args []string) error {
 	}
 
 	if writeChangesetFlag {
-		cwd, cwdErr := os.Getwd()
-		if cwdErr != nil {
-			return cwdErr
-		}
-		return writeChangesetFiles(cwd, changesets)
+		return writeChangesetFiles(cwd, idx, changesets)
 	}
-	return applyChangesets(changesets)
+	return applyChangesets(cfg, clk, cwd, idx, changesets)
 }
 
-func writeChangesetFiles(root string, changesets []tui.Changeset) error {
+func writeChangesetFiles(root string, idx *chart.Index, changesets []tui.Changeset) error {
 	for _, cs := range changesets {
-		entries := []changeset.Entry{{Chart: cs.Chart.Name, Bump: cs.Bump}}
+		label := idx.Label(cs.Chart)
+		entries := []changeset.Entry{{Chart: label, Bump: cs.Bump, AppVersion: cs.AppVersion}}
 		path, err := changeset.Write(root, entries, cs.Message)
 		if err != nil {
 			return fmt.Errorf("writing changeset: %w", err)
 		}
-		fmt.Printf("  %s: %s changeset -> %s\n", cs.Chart.Name, cs.Bump, filepath.Base(path))
+		fmt.Printf("  %s: %s changeset -> %s\n", label, cs.Bump, filepath.Base(path))
 	}
 	fmt.Printf("\n%d changeset(s) written to .helmver/\n", len(changesets))
 	return nil
 }
 
-func applyChangesets(changesets []tui.Changeset) error {
+// applyChangesets releases the charts picked in the TUI the same way apply
+// releases changeset files: dependents are cascaded, every file is planned
+// first and then written at once, and nothing is left half-written if a
+// write fails.
+func applyChangesets(cfg *config.Config, clk *clock.Clock, root string, idx *chart.Index, changesets []tui.Changeset) error {
+	rels := make([]*apply.Release, 0, len(changesets))
 	for _, cs := range changesets {
-		oldVer := cs.Chart.Version
-		if err := cs.Chart.SetVersion(cs.NewVer); err != nil {
-			return fmt.Errorf("updating %s: %w", cs.Chart.Path, err)
-		}
-
-		if err := changelog.Prepend(cs.Chart.Dir, cs.NewVer, cs.Message); err != nil {
-			return fmt.Errorf("updating changelog for %s: %w", cs.Chart.Name, err)
+		rel, err := apply.NewRelease(idx, cs.Chart, cs.Bump, cs.AppVersion, []changelog.Change{{Message: cs.Message}}, preID)
+		if err != nil {
+			return err
 		}
+		rels = append(rels, rel)
+	}
 
-		fmt.Printf("  %s: %s -> %s\n", cs.Chart.Name, oldVer, cs.NewVer)
+	plan, err := apply.FromReleases(idx, rels, apply.Options{
+		Root:   root,
+		PreID:  preID,
+		Config: cfg,
+		Clock:  clk,
+	})
+	if err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if err := plan.Execute(); err != nil {
+		return fmt.Errorf("applying changes: %w", err)
 	}
 
-	fmt.Printf("\n%d chart(s) updated\n", len(changesets))
+	for _, rel := range plan.Releases {
+		fmt.Printf("  %s: %s -> %s\n", rel.Label, rel.OldVersion, rel.NewVersion)
+	}
+	fmt.Printf("\n%d chart(s) updated\n", len(plan.Releases))
 	return nil
 }
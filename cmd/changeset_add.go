@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jordan-simonovski/helmver/internal/changeset"
+	"github.com/jordan-simonovski/helmver/internal/chart"
+)
+
+var (
+	addCharts      []string
+	addBump        string
+	addMessage     string
+	addMessageFile string
+	addAppVersion  string
+	addType        string
+	addIssues      []string
+	addPR          string
+	addAuthors     []string
+)
+
+var changesetAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Write a changeset file without the interactive TUI",
+	Long:  "Writes a single .helmver/ changeset file for one or more charts. Intended for bots, scripts and editor integrations. Charts are referenced by name or by path and must exist under --dir. The message comes from --message or --message-file ('-' reads stdin).",
+	Example: `  helmver changeset add --chart api --bump minor --message "Add /healthz endpoint"
+  helmver changeset add --chart api --chart worker --bump patch --message-file notes.md
+  git log -1 --format=%B | helmver changeset add --chart charts/api --bump patch --message-file -`,
+	Args: cobra.NoArgs,
+	RunE: runChangesetAdd,
+}
+
+func init() {
+	changesetAddCmd.Flags().StringArrayVar(&addCharts, "chart", nil, "chart name or path to bump (repeatable)")
+	changesetAddCmd.Flags().StringVar(&addBump, "bump", "", "bump type: "+strings.Join(chart.BumpKinds, ", "))
+	changesetAddCmd.Flags().StringVar(&addMessage, "message", "", "changelog message")
+	changesetAddCmd.Flags().StringVar(&addMessageFile, "message-file", "", "read the changelog message from a file, or '-' for stdin")
+	changesetAddCmd.Flags().StringVar(&addAppVersion, "app-version", "", "new appVersion, or a bump type to apply to the current appVersion")
+	changesetAddCmd.Flags().StringVar(&addType, "type", "", "change type: "+strings.Join(changeset.ChangeTypes, ", "))
+	changesetAddCmd.Flags().StringArrayVar(&addIssues, "issue", nil, "related issue, e.g. #123 or a URL (repeatable)")
+	changesetAddCmd.Flags().StringVar(&addPR, "pr", "", "related pull request")
+	changesetAddCmd.Flags().StringArrayVar(&addAuthors, "author", nil, "author of the change (repeatable)")
+	_ = changesetAddCmd.MarkFlagRequired("chart")
+	_ = changesetAddCmd.MarkFlagRequired("bump")
+	changesetAddCmd.MarkFlagsMutuallyExclusive("message", "message-file")
+	changesetCmd.AddCommand(changesetAddCmd)
+}
+
+func runChangesetAdd(cmd *cobra.Command, args []string) error {
+	if !chart.ValidBump(addBump) {
+		return fmt.Errorf("invalid bump type %q (use one of: %s)", addBump, strings.Join(chart.BumpKinds, ", "))
+	}
+
+	if addType != "" && !changeset.ValidType(addType) {
+		return fmt.Errorf("invalid type %q (use one of: %s)", addType, strings.Join(changeset.ChangeTypes, ", "))
+	}
+
+	message, err := readAddMessage(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	chartPaths, err := chart.Discover(absDir, exclude)
+	if err != nil {
+		return fmt.Errorf("discovering charts: %w", err)
+	}
+	var all []*chart.Chart
+	for _, p := range chartPaths {
+		c, err := chart.Load(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			continue
+		}
+		all = append(all, c)
+	}
+	idx := chart.NewIndex(cwd, all)
+
+	var entries []changeset.Entry
+	seen := make(map[string]bool)
+	for _, ref := range addCharts {
+		c, err := idx.Resolve(ref)
+		if errors.Is(err, chart.ErrNotFound) {
+			return fmt.Errorf("chart %q not found under %s", ref, dir)
+		}
+		if err != nil {
+			return err
+		}
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		// Reject what apply would reject, such as a bump for a chart
+		// without an appVersion, before the file is written.
+		if addAppVersion != "" {
+			if _, err := chart.ResolveAppVersion(c.AppVersion, addAppVersion, preID); err != nil {
+				return fmt.Errorf("--app-version for %s: %w", idx.Label(c), err)
+			}
+		}
+		entries = append(entries, changeset.Entry{Chart: idx.Label(c), Bump: addBump, AppVersion: addAppVersion})
+	}
+
+	path, err := changeset.WriteFile(cwd, &changeset.File{
+		Entries: entries,
+		Message: message,
+		Metadata: changeset.Metadata{
+			Type:    addType,
+			Issues:  addIssues,
+			PR:      addPR,
+			Authors: addAuthors,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		fmt.Printf("  %s: %s\n", e.Chart, e.Bump)
+	}
+	fmt.Printf("\nchangeset written to .helmver/%s\n", filepath.Base(path))
+	return nil
+}
+
+// readAddMessage returns the changelog message from --message or
+// --message-file. The message must not be empty.
+func readAddMessage(stdin io.Reader) (string, error) {
+	message := addMessage
+	switch addMessageFile {
+	case "":
+	case "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading message from stdin: %w", err)
+		}
+		message = string(data)
+	default:
+		data, err := os.ReadFile(addMessageFile)
+		if err != nil {
+			return "", fmt.Errorf("reading message file: %w", err)
+		}
+		message = string(data)
+	}
+
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	if message == "" {
+		return "", errors.New("a changelog message is required (use --message or --message-file)")
+	}
+	return message, nil
+}
@@ -9,22 +9,44 @@ import (
 
 	"github.com/jordan-simonovski/helmver/internal/chart"
 	"github.com/jordan-simonovski/helmver/internal/check"
+	"github.com/jordan-simonovski/helmver/internal/config"
 	"github.com/jordan-simonovski/helmver/internal/git"
 )
 
-var requireChangeset bool
+var (
+	requireChangeset bool
+	checkStaged      bool
+	checkWorkingTree bool
+)
 
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check if any chart versions are stale",
-	Long:  "Scans for Chart.yaml files and reports which charts have file changes relative to --base without a corresponding version bump. Exits 1 if any charts are stale (CI-friendly). Use --require-changeset to accept pending .helmver/ changeset files as a valid intent to bump.",
+	Long:  "Scans for Chart.yaml files and reports which charts have file changes relative to --base without a corresponding version bump. Exits 1 if any charts are stale (CI-friendly). Use --require-changeset to accept pending .helmver/ changeset files as a valid intent to bump. With --staged or --working-tree, the index or working tree is compared with the merge-base instead of HEAD, so changes that are not committed yet count; use --base HEAD to check only those. Charts whose version changed are checked against the base version: a lowered or unparsable version fails, and a bump that skips versions warns (or fails, with versions.nonAdjacent: error in .helmver/config.yaml). Changes to values.yaml and values.schema.json must be covered by the bump: removed keys, type changes and newly required schema properties need a major bump, added keys a minor one. Incompatible changes to CRDs in crds/ need a major bump, and other CRD changes are reported as warnings.",
 	RunE:  runCheck,
 }
 
 func init() {
 	checkCmd.Flags().BoolVar(&requireChangeset, "require-changeset", false, "accept pending .helmver/ changeset files; stale charts with a changeset are not flagged")
+	checkCmd.Flags().BoolVar(&checkStaged, "staged", false, "include staged changes and read Chart.yaml versions from the index (for pre-commit hooks)")
+	checkCmd.Flags().BoolVar(&checkWorkingTree, "working-tree", false, "include uncommitted and untracked changes in the working tree")
+	checkCmd.MarkFlagsMutuallyExclusive("staged", "working-tree")
 }
 
+// checkMode returns the git.Mode selected by --staged and --working-tree.
+func checkMode() git.Mode {
+	switch {
+	case checkStaged:
+		return git.Staged
+	case checkWorkingTree:
+		return git.WorkingTree
+	}
+	return git.Committed
+}
+
+// maxChangedFiles caps the changed files printed under each stale chart.
+const maxChangedFiles = 10
+
 func runCheck(cmd *cobra.Command, args []string) error {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
@@ -54,20 +76,47 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return err
+	}
+
 	result, err := check.Run(check.Options{
 		Dir:              dir,
 		Base:             base,
 		Exclude:          exclude,
 		RequireChangeset: requireChangeset,
+		ChangesetRoot:    cwd,
+		Config:           cfg,
+		TagPattern:       tagPattern,
+		Mode:             checkMode(),
 	})
 	if err != nil {
 		return err
 	}
 
+	for _, c := range result.BumpedCharts {
+		bump := c.Bump
+		if bump == "" {
+			bump = "unknown bump"
+		}
+		fmt.Printf("  %-30s %s -> %s  (%s)\n", c.Name, c.BaseVersion, c.Version, bump)
+	}
+	for _, f := range result.Findings {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s [%s]\n", f.Severity, f.Chart, f.Message, f.Rule)
+	}
+
 	if result.AllUpToDate {
 		for _, c := range result.CoveredCharts {
 			fmt.Printf("  %-30s %s  (has changeset)\n", c.Name, c.Version)
 		}
+		if result.HasErrors() {
+			os.Exit(1)
+		}
 		fmt.Println("all charts up to date")
 		return nil
 	}
@@ -75,6 +124,13 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	fmt.Printf("%d chart(s) need a version bump:\n\n", len(result.StaleCharts))
 	for _, c := range result.StaleCharts {
 		fmt.Printf("  %-30s %s  (%s)\n", c.Name, c.Version, c.Dir)
+		for i, f := range c.ChangedFiles {
+			if i == maxChangedFiles {
+				fmt.Printf("      ... and %d more\n", len(c.ChangedFiles)-maxChangedFiles)
+				break
+			}
+			fmt.Printf("      %s\n", f)
+		}
 	}
 	for _, c := range result.CoveredCharts {
 		fmt.Printf("  %-30s %s  (has changeset)\n", c.Name, c.Version)
@@ -5,10 +5,13 @@ import (
 )
 
 var (
-	version = "dev"
-	dir     string
-	base    string
-	exclude []string
+	version    = "dev"
+	dir        string
+	base       string
+	exclude    []string
+	preID      string
+	date       string
+	tagPattern string
 )
 
 var rootCmd = &cobra.Command{
@@ -20,11 +23,13 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.PersistentFlags().StringVar(&dir, "dir", ".", "root directory to scan for Chart.yaml files")
 	rootCmd.PersistentFlags().StringVar(&base, "base", "", "base git ref to compare against; auto-detected from CI env (GITHUB_BASE_REF, BITBUCKET_PR_DESTINATION_BRANCH, CI_MERGE_REQUEST_TARGET_BRANCH_NAME, CI_DEFAULT_BRANCH), then remote HEAD, falls back to origin/main")
+	rootCmd.PersistentFlags().StringVar(&tagPattern, "tag-pattern", "", "release tag pattern, e.g. {name}-{version}: charts are compared with their latest matching tag instead of --base, and apply --tag names its tags with it; overrides staleness.tagPattern")
 	rootCmd.PersistentFlags().StringSliceVar(&exclude, "exclude", nil, "glob patterns to exclude from chart discovery (repeatable, matched against path relative to --dir)")
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(changesetCmd)
 	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(changelogCmd)
 	rootCmd.Version = version
 }
 
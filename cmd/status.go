@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/jordan-simonovski/helmver/internal/check"
+	"github.com/jordan-simonovski/helmver/internal/config"
 )
 
 var (
@@ -27,11 +28,23 @@ func init() {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return err
+	}
+
 	result, err := check.Run(check.Options{
 		Dir:              dir,
 		Base:             base,
 		Exclude:          exclude,
 		RequireChangeset: requireChangeset,
+		ChangesetRoot:    cwd,
+		Config:           cfg,
+		TagPattern:       tagPattern,
 	})
 	if err != nil {
 		return err
@@ -43,7 +56,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Print(out)
-	if !result.AllUpToDate {
+	if !result.AllUpToDate || result.HasErrors() {
 		os.Exit(1)
 	}
 	return nil
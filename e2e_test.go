@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -280,6 +281,78 @@ func TestE2E_Check_Monorepo_AfterBump(t *testing.T) {
 	}
 }
 
+func TestE2E_Check_VersionDowngrade(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.4.0\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-m", "init chart")
+	git(t, dir, "branch", "base")
+
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.3.9\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-m", "lower version")
+
+	out, code := helmver(t, dir, "check", "--base", "base")
+	if code != 1 {
+		t.Errorf("expected exit 1, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "error: myapp: version lowered from 1.4.0 to 1.3.9 [version-downgrade]") {
+		t.Errorf("expected downgrade error, got:\n%s", out)
+	}
+
+	// A bump that skips versions only warns by default.
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 3.0.0\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-m", "skip a major")
+
+	out, code = helmver(t, dir, "check", "--base", "base")
+	if code != 0 {
+		t.Errorf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "1.4.0 -> 3.0.0  (major)") || !strings.Contains(out, "warning: myapp: major bump from 1.4.0 to 3.0.0 skips versions") {
+		t.Errorf("expected inferred bump and warning, got:\n%s", out)
+	}
+}
+
+func TestE2E_Check_StagedAndWorkingTree(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "values.yaml"), "key: val\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-m", "init chart")
+	git(t, dir, "branch", "base")
+
+	writeFile(t, filepath.Join(dir, "values.yaml"), "key: changed\n")
+	git(t, dir, "add", "values.yaml")
+
+	if out, code := helmver(t, dir, "check", "--base", "base"); code != 0 {
+		t.Errorf("committed changes only: expected exit 0, got %d. output:\n%s", code, out)
+	}
+	for _, mode := range []string{"--staged", "--working-tree"} {
+		out, code := helmver(t, dir, "check", mode, "--base", "base")
+		if code != 1 || !strings.Contains(out, "need a version bump") {
+			t.Errorf("%s: expected a stale chart, got exit %d. output:\n%s", mode, code, out)
+		}
+	}
+
+	// A bump that is not staged yet does not count for --staged.
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.0.1\n")
+	if out, code := helmver(t, dir, "check", "--staged", "--base", "base"); code != 1 {
+		t.Errorf("unstaged bump: expected exit 1, got %d. output:\n%s", code, out)
+	}
+	if out, code := helmver(t, dir, "check", "--working-tree", "--base", "base"); code != 0 {
+		t.Errorf("working tree bump: expected exit 0, got %d. output:\n%s", code, out)
+	}
+	git(t, dir, "add", "Chart.yaml")
+	if out, code := helmver(t, dir, "check", "--staged", "--base", "base"); code != 0 {
+		t.Errorf("staged bump: expected exit 0, got %d. output:\n%s", code, out)
+	}
+
+	if out, code := helmver(t, dir, "check", "--staged", "--working-tree"); code == 0 || !strings.Contains(out, "none of the others can be") {
+		t.Errorf("expected --staged and --working-tree to conflict, got exit %d. output:\n%s", code, out)
+	}
+}
+
 func TestE2E_Changeset_NotGitRepo_NoError(t *testing.T) {
 	// changeset should NOT fail with "not a git repo" anymore.
 	// It will fail because there's no TTY, but the error should NOT be about git.
@@ -432,20 +505,488 @@ func TestE2E_Apply_MultipleCharts(t *testing.T) {
 	}
 }
 
+func TestE2E_Apply_AppVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\nappVersion: \"1.4.2\"\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": {bump: minor, appVersion: \"1.5.0\"}\n---\n\nShip app 1.5.0\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "myapp: 1.0.0 -> 1.1.0 (minor), appVersion 1.4.2 -> 1.5.0") {
+		t.Errorf("expected appVersion in output, got:\n%s", out)
+	}
+
+	raw := readFileE2E(t, filepath.Join(dir, "Chart.yaml"))
+	if !strings.Contains(raw, `appVersion: "1.5.0"`) {
+		t.Errorf("appVersion not updated:\n%s", raw)
+	}
+	cl := readFileE2E(t, filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(cl, "Updated appVersion to 1.5.0") {
+		t.Errorf("changelog should mention appVersion:\n%s", cl)
+	}
+}
+
+func TestE2E_Apply_InvalidPreID(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": preminor\n---\n\nTry it\n")
+
+	for _, id := range []string{"rc 1", "", "01"} {
+		out, code := helmver(t, dir, "apply", "--preid", id)
+		if code == 0 || !strings.Contains(out, "invalid pre-release identifier") {
+			t.Errorf("--preid %q: expected an error, got exit %d:\n%s", id, code, out)
+		}
+	}
+	if raw := readFileE2E(t, filepath.Join(dir, "Chart.yaml")); !strings.Contains(raw, "version: 1.0.0") {
+		t.Errorf("Chart.yaml changed after a refused apply:\n%s", raw)
+	}
+}
+
+func TestE2E_Apply_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	chartYAML := "apiVersion: v2\nname: myapp\nversion: 1.0.0\n"
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), chartYAML)
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": minor\n---\n\nAdd feature\n")
+
+	out, code := helmver(t, dir, "apply", "--dry-run")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	for _, want := range []string{
+		"myapp: 1.0.0 -> 1.1.0 (minor)",
+		"-version: 1.0.0\n+version: 1.1.0\n",
+		"+++ b/CHANGELOG.md",
+		"--- a/.helmver/001.md",
+		"1 chart(s) would be updated, 1 changeset(s) would be consumed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+
+	if got := readFileE2E(t, filepath.Join(dir, "Chart.yaml")); got != chartYAML {
+		t.Errorf("dry run modified Chart.yaml:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CHANGELOG.md")); !os.IsNotExist(err) {
+		t.Error("dry run should not create CHANGELOG.md")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".helmver", "001.md")); err != nil {
+		t.Error("dry run should not consume changesets")
+	}
+}
+
+func TestE2E_Apply_DryRun_JSON(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": patch\n---\n\nFix bug\n")
+
+	out, code := helmver(t, dir, "apply", "--dry-run", "--format", "json")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	var payload struct {
+		DryRun   bool `json:"dryRun"`
+		Releases []struct {
+			Chart      string `json:"chart"`
+			NewVersion string `json:"newVersion"`
+		} `json:"releases"`
+		Changes []struct {
+			Path   string `json:"path"`
+			Action string `json:"action"`
+		} `json:"changes"`
+		Consumed []string `json:"consumed"`
+	}
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if !payload.DryRun || len(payload.Releases) != 1 || payload.Releases[0].NewVersion != "1.0.1" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if len(payload.Changes) != 3 || payload.Changes[1].Action != "create" || payload.Changes[2].Action != "delete" {
+		t.Errorf("unexpected changes: %+v", payload.Changes)
+	}
+	if len(payload.Consumed) != 1 || payload.Consumed[0] != ".helmver/001.md" {
+		t.Errorf("unexpected consumed: %v", payload.Consumed)
+	}
+}
+
+func TestE2E_Apply_ChangelogTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "config.yaml"),
+		"changelog:\n  templateFile: .helmver/changelog.tmpl\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "changelog.tmpl"),
+		"## {{.Chart}} v{{.Version}} ({{.Bump}})\n\n{{.Body}}\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": minor\n---\n\nAdd feature\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	cl := readFileE2E(t, filepath.Join(dir, "CHANGELOG.md"))
+	if cl != "# Changelog\n\n## myapp v1.1.0 (minor)\n\nAdd feature\n\n" {
+		t.Errorf("unexpected changelog:\n%s", cl)
+	}
+}
+
+func TestE2E_Apply_Date(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "config.yaml"),
+		"date:\n  timezone: America/New_York\n  layout: January 2, 2006\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": minor\n---\n\nAdd feature\n")
+
+	// 2025-03-02T00:00:00Z is still March 1 in New York.
+	cmd := exec.Command(binary, "apply")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "SOURCE_DATE_EPOCH=1740873600")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("apply failed: %v\n%s", err, out)
+	}
+	cl := readFileE2E(t, filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(cl, "## 1.1.0 (March 1, 2025)\n") {
+		t.Errorf("unexpected changelog:\n%s", cl)
+	}
+
+	writeFile(t, filepath.Join(dir, ".helmver", "002.md"),
+		"---\n\"myapp\": patch\n---\n\nFix bug\n")
+	out, code := helmver(t, dir, "apply", "--date", "2025-04-10")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	cl = readFileE2E(t, filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(cl, "## 1.1.1 (April 10, 2025)\n") {
+		t.Errorf("unexpected changelog:\n%s", cl)
+	}
+
+	out, code = helmver(t, dir, "apply", "--date", "10/04/2025")
+	if code == 0 || !strings.Contains(out, "invalid date") {
+		t.Errorf("expected an invalid date error, got %d:\n%s", code, out)
+	}
+}
+
+func TestE2E_Apply_VersionCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "CHANGELOG.md"),
+		"# Changelog\n\n## 1.1.0 (2025-01-01)\n\nFirst attempt\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"myapp\": minor\n---\n\nSecond attempt\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code == 0 {
+		t.Fatalf("expected a collision error, got exit 0. output:\n%s", out)
+	}
+	if !strings.Contains(out, "already has an entry for this version: 1.1.0") || !strings.Contains(out, "--merge") {
+		t.Errorf("expected collision message, got:\n%s", out)
+	}
+	if v := readFileE2E(t, filepath.Join(dir, "Chart.yaml")); !strings.Contains(v, "version: 1.0.0") {
+		t.Errorf("Chart.yaml changed after refused apply:\n%s", v)
+	}
+
+	out, code = helmver(t, dir, "apply", "--merge")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	cl := readFileE2E(t, filepath.Join(dir, "CHANGELOG.md"))
+	if cl != "# Changelog\n\n## 1.1.0 (2025-01-01)\n\nFirst attempt\n\nSecond attempt\n" {
+		t.Errorf("unexpected changelog:\n%s", cl)
+	}
+}
+
+func TestE2E_Apply_CommitAndTag(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "charts", "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "charts", "web", "Chart.yaml"),
+		"apiVersion: v2\nname: web\nversion: 0.3.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"api\": minor\n\"web\": patch\ntype: fixed\n---\n\nFix probes\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-m", "init")
+	// An unrelated staged file stays out of the release commit.
+	writeFile(t, filepath.Join(dir, "notes.txt"), "wip\n")
+	git(t, dir, "add", "notes.txt")
+
+	out, code := helmver(t, dir, "apply", "--tag")
+	if code == 0 || !strings.Contains(out, "--tag requires --commit") {
+		t.Fatalf("expected --tag to require --commit, got exit %d:\n%s", code, out)
+	}
+
+	out, code = helmver(t, dir, "apply", "--commit", "--tag", "--dry-run")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "  - api: 1.0.0 -> 1.1.0 (minor)\n") || !strings.Contains(out, "tags:\n  api-1.1.0\n  web-0.3.1\n") {
+		t.Errorf("expected the commit message and tags in the dry run, got:\n%s", out)
+	}
+
+	out, code = helmver(t, dir, "apply", "--commit", "--tag")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "tagged api-1.1.0") || !strings.Contains(out, "tagged web-0.3.1") {
+		t.Errorf("expected tags in output, got:\n%s", out)
+	}
+
+	files := gitOutput(t, dir, "show", "--name-status", "--format=%s", "HEAD")
+	want := "chore: apply helmver changesets\n\nD\t.helmver/001.md\nA\tcharts/api/CHANGELOG.md\nM\tcharts/api/Chart.yaml\nA\tcharts/web/CHANGELOG.md\nM\tcharts/web/Chart.yaml"
+	if files != want {
+		t.Errorf("release commit:\n%s\nwant:\n%s", files, want)
+	}
+	if staged := gitOutput(t, dir, "diff", "--cached", "--name-only"); staged != "notes.txt" {
+		t.Errorf("expected notes.txt to stay staged, got %q", staged)
+	}
+	msg := gitOutput(t, dir, "tag", "-l", "--format=%(contents)", "web-0.3.1")
+	if !strings.HasPrefix(msg, "web 0.3.1\n\n### Fixed\n\n- Fix probes") {
+		t.Errorf("unexpected tag message:\n%s", msg)
+	}
+	if tagged := gitOutput(t, dir, "rev-list", "-n1", "api-1.1.0"); tagged != gitOutput(t, dir, "rev-parse", "HEAD") {
+		t.Errorf("api-1.1.0 points at %s, not HEAD", tagged)
+	}
+
+	// The new tags are the baseline of the next check.
+	writeFile(t, filepath.Join(dir, "charts", "web", "values.yaml"), "a: 1\n")
+	git(t, dir, "add", "charts/web/values.yaml")
+	git(t, dir, "commit", "-m", "change web")
+	out, code = helmver(t, dir, "check", "--tag-pattern", "{name}-{version}", "--base", "HEAD")
+	if code != 1 || !strings.Contains(out, "web") || strings.Contains(out, "api") {
+		t.Errorf("expected only web to be stale, got exit %d:\n%s", code, out)
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestE2E_Apply_DuplicateNames_ByPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "envs", "prod", "Chart.yaml"),
+		"apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "envs", "stage", "Chart.yaml"),
+		"apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"envs/stage\": minor\n---\n\nStage only\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "envs/stage: 1.0.0 -> 1.1.0 (minor)") {
+		t.Errorf("expected stage bump labelled by path, got:\n%s", out)
+	}
+	prod := readFileE2E(t, filepath.Join(dir, "envs", "prod", "Chart.yaml"))
+	if !strings.Contains(prod, "version: 1.0.0") {
+		t.Errorf("prod should be untouched:\n%s", prod)
+	}
+}
+
+func TestE2E_Apply_DuplicateNames_AmbiguousError(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "envs", "prod", "Chart.yaml"),
+		"apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "envs", "stage", "Chart.yaml"),
+		"apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
+		"---\n\"app\": minor\n---\n\nWhich one?\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code == 0 {
+		t.Fatalf("expected failure for ambiguous chart name, got:\n%s", out)
+	}
+	if !strings.Contains(out, "ambiguous") || !strings.Contains(out, "envs/prod, envs/stage") {
+		t.Errorf("expected ambiguity error listing paths, got:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".helmver", "001.md")); err != nil {
+		t.Error("changeset file should be kept when apply fails")
+	}
+}
+
 func TestE2E_Apply_UnknownChart_Warning(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, filepath.Join(dir, "Chart.yaml"),
 		"apiVersion: v2\nname: real\nversion: 1.0.0\n")
 	writeFile(t, filepath.Join(dir, ".helmver", "001.md"),
-		"---\n\"ghost\": patch\n---\n\nThis chart does not exist\n")
+		"---\n\"real\": minor\n\"ghost\": patch\n---\n\nThis chart does not exist\n")
 
 	out, code := helmver(t, dir, "apply")
 	if code != 0 {
 		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
 	}
+	if got := readFileE2E(t, filepath.Join(dir, "Chart.yaml")); !strings.Contains(got, "version: 1.0.0") {
+		t.Errorf("no entry of a kept changeset should apply:\n%s", got)
+	}
 	if !strings.Contains(out, "ghost") {
 		t.Errorf("expected warning about ghost chart, got:\n%s", out)
 	}
+	if _, err := os.Stat(filepath.Join(dir, ".helmver", "001.md")); err != nil {
+		t.Error("changeset referencing an unknown chart should be kept")
+	}
+}
+
+// --- changeset add tests ---
+
+func TestE2E_ChangesetAdd_MultipleCharts(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "charts", "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "charts", "worker", "Chart.yaml"),
+		"apiVersion: v2\nname: worker\nversion: 0.2.0\n")
+
+	out, code := helmver(t, dir, "changeset", "add",
+		"--chart", "api", "--chart", "charts/worker", "--bump", "minor", "--message", "Shared config migration")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, ".helmver", "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected one changeset file, got %v", files)
+	}
+	raw := readFileE2E(t, files[0])
+	want := "---\n\"api\": minor\n\"worker\": minor\n---\n\nShared config migration\n"
+	if raw != want {
+		t.Errorf("unexpected changeset:\n%s\nwant:\n%s", raw, want)
+	}
+
+	out, code = helmver(t, dir, "apply")
+	if code != 0 {
+		t.Fatalf("apply failed with %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "2 chart(s) updated, 1 changeset(s) consumed") {
+		t.Errorf("expected both charts applied, got:\n%s", out)
+	}
+}
+
+func TestE2E_ChangesetAdd_MessageFromStdin(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+
+	cmd := exec.Command(binary, "changeset", "add", "--chart", "myapp", "--bump", "patch",
+		"--app-version", "2.1.0", "--message-file", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader("Bump to app 2.1.0\r\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("changeset add failed: %v\n%s", err, out)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, ".helmver", "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected one changeset file, got %v", files)
+	}
+	raw := readFileE2E(t, files[0])
+	if !strings.Contains(raw, `"myapp": {bump: patch, appVersion: "2.1.0"}`) || !strings.HasSuffix(raw, "\n\nBump to app 2.1.0\n") {
+		t.Errorf("unexpected changeset:\n%s", raw)
+	}
+}
+
+func TestE2E_ChangesetAdd_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+
+	out, code := helmver(t, dir, "changeset", "add", "--chart", "myapp", "--bump", "patch",
+		"--type", "fixed", "--issue", "#12", "--pr", "34", "--author", "alice", "--message", "Fix probe")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, ".helmver", "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected one changeset file, got %v", files)
+	}
+	want := "---\n\"myapp\": patch\ntype: fixed\nissues: [\"#12\"]\npr: \"34\"\nauthors: [\"alice\"]\n---\n\nFix probe\n"
+	if raw := readFileE2E(t, files[0]); raw != want {
+		t.Errorf("unexpected changeset:\n%s\nwant:\n%s", raw, want)
+	}
+
+	if out, code := helmver(t, dir, "changeset", "add", "--chart", "myapp", "--bump", "patch", "--type", "improved", "--message", "x"); code == 0 {
+		t.Errorf("expected an invalid --type to fail, got:\n%s", out)
+	}
+}
+
+func TestE2E_ChangesetAdd_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"),
+		"apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+
+	cases := map[string][]string{
+		"unknown chart": {"--chart", "ghost", "--bump", "patch", "--message", "x"},
+		"invalid bump":  {"--chart", "myapp", "--bump", "huge", "--message", "x"},
+		"no message":    {"--chart", "myapp", "--bump", "patch"},
+		"no chart":      {"--bump", "patch", "--message", "x"},
+		// myapp has no appVersion to bump.
+		"app-version bump": {"--chart", "myapp", "--bump", "patch", "--app-version", "minor", "--message", "x"},
+	}
+	for name, args := range cases {
+		out, code := helmver(t, dir, append([]string{"changeset", "add"}, args...)...)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit, got output:\n%s", name, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".helmver")); !os.IsNotExist(err) {
+		t.Error("no changeset should be written on error")
+	}
+}
+
+// --- changelog show tests ---
+
+func TestE2E_ChangelogShow(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "charts", "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 1.3.0\n")
+	writeFile(t, filepath.Join(dir, "charts", "api", "CHANGELOG.md"),
+		"# Changelog\n\n## 1.3.0 (2025-03-02)\n\n### Added\n\n- Add HPA\n\n### Fixed\n\n- Fix probe\n\n\n## 1.2.0 (2025-01-10)\n\nPlain message\n")
+
+	out, code := helmver(t, dir, "changelog", "show", "api")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if out != "### Added\n\n- Add HPA\n\n### Fixed\n\n- Fix probe\n" {
+		t.Errorf("unexpected markdown:\n%q", out)
+	}
+
+	out, code = helmver(t, dir, "changelog", "show", "charts/api", "--version", "1.2.0", "--format", "json")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	var payload struct {
+		Chart   string `json:"chart"`
+		Version string `json:"version"`
+		Date    string `json:"date"`
+		Body    string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if payload.Chart != "api" || payload.Version != "1.2.0" || payload.Date != "2025-01-10" || payload.Body != "Plain message" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+
+	if out, code := helmver(t, dir, "changelog", "show", "api", "--version", "9.9.9"); code == 0 {
+		t.Errorf("expected an unknown version to fail, got:\n%s", out)
+	}
 }
 
 // --- check --require-changeset tests ---
@@ -0,0 +1,458 @@
+// Package apply turns pending changesets into chart releases. Every change
+// is planned in memory first; Plan.Execute then writes all files atomically
+// and restores the originals if any write fails.
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/jordan-simonovski/helmver/internal/artifacthub"
+	"github.com/jordan-simonovski/helmver/internal/changelog"
+	"github.com/jordan-simonovski/helmver/internal/changeset"
+	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/clock"
+	"github.com/jordan-simonovski/helmver/internal/config"
+	"github.com/jordan-simonovski/helmver/internal/git"
+	"github.com/jordan-simonovski/helmver/internal/releases"
+)
+
+// Options configures Build.
+type Options struct {
+	Dir     string   // directory to discover charts under (absolute)
+	Root    string   // changeset root holding .helmver/; chart IDs are relative to it
+	Exclude []string // discovery exclude patterns
+	PreID   string   // pre-release identifier for pre* bumps
+	Config  *config.Config
+	Clock   *clock.Clock // release date source; clock.System when nil
+	// Merge adds a release's notes to an existing CHANGELOG entry for the
+	// same version instead of refusing to apply; see checkCollision.
+	Merge bool
+}
+
+// Release is the computed version change for one chart.
+type Release struct {
+	Chart         *chart.Chart
+	Label         string // chart name, or its path when the name is ambiguous
+	Bump          string
+	OldVersion    string
+	NewVersion    string
+	OldAppVersion string
+	NewAppVersion string // empty when appVersion does not change
+	Changes       []changelog.Change
+	Dependencies  []DependencyUpdate
+	// Notes is the release's CHANGELOG.md entry without its heading, as
+	// planned; with --merge it includes the notes already there.
+	Notes string
+}
+
+// DependencyUpdate records a file:// dependency pin that follows a bump.
+type DependencyUpdate struct {
+	Dir     string // dependency chart directory
+	Name    string
+	Version string
+}
+
+// FileChange is one planned file write or deletion.
+type FileChange struct {
+	Path   string
+	Before []byte // nil when the file does not exist yet
+	After  []byte // nil when the file is deleted
+}
+
+// Plan is the full set of changes an apply run would make.
+type Plan struct {
+	Releases []*Release
+	Changes  []FileChange
+	Consumed []string // changeset files whose entries were all applied
+	Kept     []string // changeset files skipped and left in place (unknown charts)
+	Warnings []string
+}
+
+// Build reads the pending changesets under opts.Root, resolves them against
+// the charts under opts.Dir and returns the plan. It writes nothing. A nil
+// plan with a nil error means there are no pending changesets.
+func Build(opts Options) (*Plan, error) {
+	files, err := changeset.Discover(opts.Root)
+	if err != nil {
+		return nil, fmt.Errorf("reading changesets: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	idx, warnings, err := loadIndex(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite every entry to its chart ID so that a chart referenced by
+	// name in one file and by path in another aggregates as one. A file
+	// that names an unknown chart is skipped as a whole and kept, so that
+	// none of its entries is applied twice once it is fixed.
+	chartsByID := make(map[string]*chart.Chart)
+	known := make([]*changeset.File, 0, len(files))
+	var consumed, kept []string
+	for _, f := range files {
+		kf := &changeset.File{Path: f.Path, Message: f.Message, Metadata: f.Metadata}
+		found := make(map[string]*chart.Chart)
+		complete := true
+		for _, e := range f.Entries {
+			c, err := idx.Resolve(e.Chart)
+			if errors.Is(err, chart.ErrNotFound) {
+				warnings = append(warnings, fmt.Sprintf("changeset references chart %q but no Chart.yaml found", e.Chart))
+				complete = false
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Path, err)
+			}
+			e.Chart = c.ID
+			kf.Entries = append(kf.Entries, e)
+			found[c.ID] = c
+		}
+		if !complete {
+			kept = append(kept, f.Path)
+			continue
+		}
+		for id, c := range found {
+			chartsByID[id] = c
+		}
+		known = append(known, kf)
+		consumed = append(consumed, f.Path)
+	}
+
+	resolved, err := changeset.Aggregate(known)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resolved))
+	for id := range resolved {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var rels []*Release
+	for _, id := range ids {
+		r := resolved[id]
+		c := chartsByID[id]
+		changes := make([]changelog.Change, 0, len(r.Changes))
+		for _, ch := range r.Changes {
+			changes = append(changes, changelog.Change{
+				Type:    ch.Type,
+				Message: ch.Message,
+				Issues:  ch.Issues,
+				PR:      ch.PR,
+				Authors: ch.Authors,
+			})
+		}
+		rel, err := NewRelease(idx, c, r.Bump, r.AppVersion, changes, opts.PreID)
+		if err != nil {
+			return nil, err
+		}
+		rels = append(rels, rel)
+	}
+
+	plan, err := FromReleases(idx, rels, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan.Warnings = append(warnings, plan.Warnings...)
+	plan.Consumed = consumed
+	plan.Kept = kept
+	for _, path := range consumed {
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		plan.Changes = append(plan.Changes, FileChange{Path: path, Before: before})
+	}
+	return plan, nil
+}
+
+// NewRelease computes the release for one chart. appVersion is either
+// empty, an explicit value, or a bump type for the current appVersion.
+func NewRelease(idx *chart.Index, c *chart.Chart, bump, appVersion string, changes []changelog.Change, preID string) (*Release, error) {
+	label := idx.Label(c)
+	newVer, err := chart.BumpVersionPre(c.Version, bump, preID)
+	if err != nil {
+		return nil, fmt.Errorf("bumping %s: %w", label, err)
+	}
+
+	rel := &Release{
+		Chart:         c,
+		Label:         label,
+		Bump:          bump,
+		OldVersion:    c.Version,
+		NewVersion:    newVer,
+		OldAppVersion: c.AppVersion,
+		Changes:       append([]changelog.Change(nil), changes...),
+	}
+	if appVersion != "" {
+		rel.NewAppVersion, err = chart.ResolveAppVersion(c.AppVersion, appVersion, preID)
+		if err != nil {
+			return nil, fmt.Errorf("bumping appVersion of %s: %w", label, err)
+		}
+		rel.Changes = append(rel.Changes, changelog.Change{Message: AppVersionNote(rel.NewAppVersion)})
+	}
+	return rel, nil
+}
+
+// FromReleases cascades releases to charts that depend on them through
+// file:// dependencies and renders the Chart.yaml and CHANGELOG.md changes,
+// and the release log when one is configured. It uses opts.PreID,
+// opts.Config, opts.Clock, opts.Merge and opts.Root (for the release log
+// path); Dir and Exclude are for Build only. The charts are edited in
+// memory only.
+func FromReleases(idx *chart.Index, rels []*Release, opts Options) (*Plan, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	renderer, err := changelog.NewRenderer(cfg.Changelog.Template, cfg.Changelog.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	rels, err = cascadeDependents(idx, rels, opts.PreID)
+	if err != nil {
+		return nil, err
+	}
+
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.System
+	}
+	date := clk.Date()
+
+	plan := &Plan{Releases: rels}
+	for _, rel := range rels {
+		c := rel.Chart
+		warning, err := checkHistory(rel, opts.Merge)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			plan.Warnings = append(plan.Warnings, warning)
+		}
+
+		before := append([]byte(nil), c.Content()...)
+
+		if err := c.UpdateVersion(rel.NewVersion); err != nil {
+			return nil, fmt.Errorf("updating %s: %w", c.Path, err)
+		}
+		if rel.NewAppVersion != "" {
+			if err := c.UpdateAppVersion(rel.NewAppVersion); err != nil {
+				return nil, fmt.Errorf("updating %s: %w", c.Path, err)
+			}
+		}
+		for _, dep := range rel.Dependencies {
+			if err := c.UpdateDependencyVersion(dep.Dir, dep.Version); err != nil {
+				return nil, fmt.Errorf("updating %s: %w", c.Path, err)
+			}
+		}
+		if err := updateArtifactHub(c, rel, cfg); err != nil {
+			return nil, fmt.Errorf("updating %s: %w", c.Path, err)
+		}
+		plan.Changes = append(plan.Changes, FileChange{Path: c.Path, Before: before, After: c.Content()})
+
+		clPath := changelog.Path(c.Dir)
+		existing, err := os.ReadFile(clPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading %s: %w", clPath, err)
+		}
+		entry := rel.Entry(cfg.Changelog.RepoURL)
+		entry.Date = date
+		var after []byte
+		if _, ferr := changelog.Parse(existing).Find(rel.NewVersion); ferr == nil {
+			if !opts.Merge {
+				return nil, fmt.Errorf("%s: %s: %w: %s (use --merge to add to it)", rel.Label, clPath, changelog.ErrVersionExists, rel.NewVersion)
+			}
+			after, err = renderer.Merge(existing, entry)
+		} else {
+			after, err = renderer.Render(existing, entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rel.Notes = entry.Body()
+		if r, err := changelog.Parse(after).Find(rel.NewVersion); err == nil {
+			rel.Notes = r.Body
+		}
+		plan.Changes = append(plan.Changes, FileChange{Path: clPath, Before: existing, After: after})
+	}
+
+	if cfg.Releases.Path != "" && len(rels) > 0 {
+		ch, err := releaseLog(rels, opts.Root, date, cfg.Releases)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, ch)
+	}
+	return plan, nil
+}
+
+// checkHistory refuses a release whose new version already appears in
+// the chart's git history, for example when changesets are applied again
+// after a rebase. With merge set it only returns a warning. Charts outside
+// a git repository are not checked.
+func checkHistory(rel *Release, merge bool) (string, error) {
+	if !git.IsRepo(rel.Chart.Dir) {
+		return "", nil
+	}
+	commit, err := git.VersionCommit(rel.Chart.Path, rel.NewVersion)
+	if err != nil {
+		return fmt.Sprintf("%s: checking version history: %s", rel.Label, err), nil
+	}
+	if commit == "" {
+		return "", nil
+	}
+	msg := fmt.Sprintf("%s: version %s was already released in commit %.12s", rel.Label, rel.NewVersion, commit)
+	if !merge {
+		return "", fmt.Errorf("%s (use --merge to apply anyway)", msg)
+	}
+	return msg, nil
+}
+
+// releaseLog records the run in the repository-level release log.
+func releaseLog(rels []*Release, root, date string, cfg config.Releases) (FileChange, error) {
+	path := cfg.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return FileChange{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	run := releases.Run{Date: date}
+	for _, rel := range rels {
+		rc := releases.Chart{
+			Chart:         rel.Label,
+			ID:            rel.Chart.ID,
+			Bump:          rel.Bump,
+			From:          rel.OldVersion,
+			To:            rel.NewVersion,
+			AppVersionNew: rel.NewAppVersion,
+			Changes:       []releases.Change{},
+		}
+		if rel.NewAppVersion != "" {
+			rc.AppVersionOld = rel.OldAppVersion
+		}
+		for _, c := range rel.Changes {
+			if c.Message != "" {
+				rc.Changes = append(rc.Changes, releases.Change{Type: c.Type, Message: c.Message})
+			}
+		}
+		run.Releases = append(run.Releases, rc)
+	}
+
+	after, err := releases.Render(existing, run, cfg.Format)
+	if err != nil {
+		return FileChange{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return FileChange{Path: path, Before: existing, After: after}, nil
+}
+
+// updateArtifactHub rewrites the artifacthub.io/changes annotation with the
+// release's changes when the config enables it or the chart already has
+// it, and sets artifacthub.io/containsSecurityUpdates to match.
+func updateArtifactHub(c *chart.Chart, rel *Release, cfg *config.Config) error {
+	_, hasChanges := c.Annotation(artifacthub.ChangesKey)
+	if !cfg.ArtifactHub.Changes && !hasChanges {
+		return nil
+	}
+
+	changes := artifacthub.FromChangelog(rel.Changes, cfg.Changelog.RepoURL)
+	value, err := artifacthub.Render(changes)
+	if err != nil {
+		return err
+	}
+	if err := c.UpdateAnnotation(artifacthub.ChangesKey, value); err != nil {
+		return err
+	}
+
+	_, hasSecurity := c.Annotation(artifacthub.SecurityKey)
+	if security := artifacthub.ContainsSecurity(changes); security || hasSecurity {
+		return c.UpdateAnnotation(artifacthub.SecurityKey, strconv.FormatBool(security))
+	}
+	return nil
+}
+
+// Entry returns the changelog template data for the release.
+func (rel *Release) Entry(repoURL string) changelog.Entry {
+	return changelog.Entry{
+		Chart:           rel.Chart.Name,
+		Version:         rel.NewVersion,
+		PreviousVersion: rel.OldVersion,
+		Bump:            rel.Bump,
+		AppVersion:      rel.NewAppVersion,
+		Changes:         rel.Changes,
+		RepoURL:         repoURL,
+	}
+}
+
+// cascadeDependents walks the file:// dependency graph outwards from every
+// release. Each dependent gets its dependency pin updated and, unless it is
+// already released, a patch bump. Dependents are appended after the charts
+// they depend on.
+func cascadeDependents(idx *chart.Index, rels []*Release, preID string) ([]*Release, error) {
+	dependents := chart.Dependents(idx.Charts())
+	byDir := make(map[string]*Release, len(rels))
+	for _, rel := range rels {
+		byDir[rel.Chart.Dir] = rel
+	}
+
+	for i := 0; i < len(rels); i++ {
+		dep := rels[i]
+		for _, parent := range dependents[dep.Chart.Dir] {
+			rel, ok := byDir[parent.Dir]
+			if !ok {
+				var err error
+				rel, err = NewRelease(idx, parent, "patch", "", nil, preID)
+				if err != nil {
+					return nil, err
+				}
+				byDir[parent.Dir] = rel
+				rels = append(rels, rel)
+			}
+			rel.Dependencies = append(rel.Dependencies, DependencyUpdate{
+				Dir:     dep.Chart.Dir,
+				Name:    dep.Chart.Name,
+				Version: dep.NewVersion,
+			})
+			rel.Changes = append(rel.Changes, changelog.Change{
+				Message: fmt.Sprintf("Updated dependency %s to %s", dep.Chart.Name, dep.NewVersion),
+			})
+		}
+	}
+	return rels, nil
+}
+
+// AppVersionNote is the changelog line recorded when a release changes appVersion.
+func AppVersionNote(appVersion string) string {
+	return fmt.Sprintf("Updated appVersion to %s", appVersion)
+}
+
+func loadIndex(opts Options) (*chart.Index, []string, error) {
+	paths, err := chart.Discover(opts.Dir, opts.Exclude)
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovering charts: %w", err)
+	}
+
+	var warnings []string
+	var charts []*chart.Chart
+	for _, p := range paths {
+		c, err := chart.Load(p)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		charts = append(charts, c)
+	}
+	return chart.NewIndex(opts.Root, charts), warnings, nil
+}
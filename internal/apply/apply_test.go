@@ -0,0 +1,468 @@
+package apply
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordan-simonovski/helmver/internal/clock"
+	"github.com/jordan-simonovski/helmver/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuild_writesNothing(t *testing.T) {
+	root := t.TempDir()
+	chartYAML := "apiVersion: v2\nname: api\nversion: 1.0.0\n"
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), chartYAML)
+	writeFile(t, filepath.Join(root, ".helmver", "one.md"), "---\napi: minor\n---\n\nAdd endpoint\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Releases) != 1 || plan.Releases[0].NewVersion != "1.1.0" {
+		t.Fatalf("unexpected releases: %+v", plan.Releases)
+	}
+	if got := readFile(t, filepath.Join(root, "api", "Chart.yaml")); got != chartYAML {
+		t.Errorf("Build modified Chart.yaml:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "api", "CHANGELOG.md")); !os.IsNotExist(err) {
+		t.Error("Build created CHANGELOG.md")
+	}
+	if len(plan.Consumed) != 1 {
+		t.Errorf("expected one consumed changeset, got %v", plan.Consumed)
+	}
+}
+
+func TestBuild_noChangesets(t *testing.T) {
+	root := t.TempDir()
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan != nil {
+		t.Errorf("expected nil plan, got %+v", plan)
+	}
+}
+
+func TestBuild_keepsChangesetsWithUnknownCharts(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	complete := filepath.Join(root, ".helmver", "complete.md")
+	partial := filepath.Join(root, ".helmver", "partial.md")
+	writeFile(t, complete, "---\napi: patch\n---\n\nFix\n")
+	writeFile(t, partial, "---\napi: minor\nghost: major\n---\n\nFeature\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(complete); !os.IsNotExist(err) {
+		t.Error("fully applied changeset should be removed")
+	}
+	if _, err := os.Stat(partial); err != nil {
+		t.Error("changeset naming an unknown chart should be kept")
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], `"ghost"`) {
+		t.Errorf("expected a warning for ghost, got %v", plan.Warnings)
+	}
+	// Only the complete file applies: a patch, not the partial file's minor.
+	if got := readFile(t, filepath.Join(root, "api", "Chart.yaml")); !strings.Contains(got, "version: 1.0.1") {
+		t.Errorf("no entry of a kept changeset should apply:\n%s", got)
+	}
+	if got := readFile(t, filepath.Join(root, "api", "CHANGELOG.md")); strings.Contains(got, "Feature") {
+		t.Errorf("kept changeset should not reach the changelog:\n%s", got)
+	}
+}
+
+func TestBuild_sameChartByNameAndPath(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "charts", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "both.md"), "---\napi: patch\ncharts/api: minor\n---\n\nFeature\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "charts", "api", "Chart.yaml")); !strings.Contains(got, "version: 1.1.0") {
+		t.Errorf("expected the minor bump:\n%s", got)
+	}
+	if got := readFile(t, filepath.Join(root, "charts", "api", "CHANGELOG.md")); strings.Count(got, "Feature") != 1 {
+		t.Errorf("message should appear once:\n%s", got)
+	}
+}
+
+func TestBuild_cascadesToDependents(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "common", "Chart.yaml"), "apiVersion: v2\nname: common\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 0.1.0\ndependencies:\n  - name: common\n    version: 1.0.0\n    repository: file://../common\n")
+	writeFile(t, filepath.Join(root, ".helmver", "one.md"), "---\ncommon: minor\n---\n\nShared helper\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Releases) != 2 {
+		t.Fatalf("expected common and api releases, got %d", len(plan.Releases))
+	}
+	api := plan.Releases[1]
+	if api.Label != "api" || api.Bump != "patch" || api.NewVersion != "0.1.1" {
+		t.Errorf("unexpected cascaded release: %+v", api)
+	}
+	if len(api.Dependencies) != 1 || api.Dependencies[0].Version != "1.1.0" {
+		t.Errorf("unexpected dependency updates: %+v", api.Dependencies)
+	}
+}
+
+func TestBuild_cascadesToFlowStyleDependencies(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "common", "Chart.yaml"), "apiVersion: v2\nname: common\nversion: 1.0.0\n")
+	web := "apiVersion: v2\nname: web\nversion: 0.1.0\ndependencies:\n  - {name: common, version: 1.0.0, repository: file://../common}\n"
+	worker := "apiVersion: v2\nname: worker\nversion: 0.1.0\ndependencies: [{name: common, version: \">=1.0.0 <2.0.0\", repository: file://../common}]\n"
+	writeFile(t, filepath.Join(root, "web", "Chart.yaml"), web)
+	writeFile(t, filepath.Join(root, "worker", "Chart.yaml"), worker)
+	writeFile(t, filepath.Join(root, ".helmver", "one.md"), "---\ncommon: minor\n---\n\nShared helper\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("version: 0.1.0", "version: 0.1.1", "version: 1.0.0,", "version: 1.1.0,").Replace(web)
+	if got := readFile(t, filepath.Join(root, "web", "Chart.yaml")); got != want {
+		t.Errorf("web Chart.yaml:\n%s\nwant:\n%s", got, want)
+	}
+	// The range already allows 1.1.0, so only the chart version changes.
+	want = strings.Replace(worker, "version: 0.1.0", "version: 0.1.1", 1)
+	if got := readFile(t, filepath.Join(root, "worker", "Chart.yaml")); got != want {
+		t.Errorf("worker Chart.yaml:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_cascadeRefusesExcludingConstraint(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "common", "Chart.yaml"), "apiVersion: v2\nname: common\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 0.1.0\ndependencies:\n  - name: common\n    version: \">=1.0.0 <1.1.0\"\n    repository: file://../common\n")
+	writeFile(t, filepath.Join(root, ".helmver", "one.md"), "---\ncommon: minor\n---\n\nShared helper\n")
+
+	if _, err := Build(Options{Dir: root, Root: root}); err == nil || !strings.Contains(err.Error(), "does not allow 1.1.0") {
+		t.Errorf("expected a constraint error, got %v", err)
+	}
+}
+
+func TestExecute_rollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	created := filepath.Join(dir, "created.txt")
+	writeFile(t, first, "original\n")
+	// A non-empty directory cannot be replaced by rename, so the last
+	// write fails after the others have been renamed into place.
+	blocked := filepath.Join(dir, "blocked")
+	writeFile(t, filepath.Join(blocked, "keep"), "x")
+	consumed := filepath.Join(dir, "changeset.md")
+	writeFile(t, consumed, "pending\n")
+
+	plan := &Plan{Changes: []FileChange{
+		{Path: first, Before: []byte("original\n"), After: []byte("changed\n")},
+		{Path: created, After: []byte("new\n")},
+		{Path: blocked, Before: []byte("x"), After: []byte("y")},
+		{Path: consumed, Before: []byte("pending\n")},
+	}}
+	if err := plan.Execute(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if got := readFile(t, first); got != "original\n" {
+		t.Errorf("first.txt not restored: %q", got)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("created.txt should be removed on rollback")
+	}
+	if _, err := os.Stat(consumed); err != nil {
+		t.Error("changeset should not be deleted when a write fails")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".helmver-") {
+			t.Errorf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestExecute_keepsFileMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Chart.yaml")
+	writeFile(t, path, "a\n")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &Plan{Changes: []FileChange{{Path: path, Before: []byte("a\n"), After: []byte("b\n")}}}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFormat_dryRunMatchesExecute(t *testing.T) {
+	root := t.TempDir()
+	chartPath := filepath.Join(root, "api", "Chart.yaml")
+	writeFile(t, chartPath, "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "one.md"), "---\napi: patch\n---\n\nFix\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Format(plan, "text", root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"api: 1.0.0 -> 1.0.1 (patch)",
+		"--- a/api/Chart.yaml\n+++ b/api/Chart.yaml\n",
+		"-version: 1.0.0\n+version: 1.0.1\n",
+		"--- /dev/null\n+++ b/api/CHANGELOG.md\n",
+		"--- a/.helmver/one.md\n+++ /dev/null\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry-run output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range plan.Changes {
+		data, err := os.ReadFile(ch.Path)
+		if ch.After == nil {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s should be deleted", ch.Path)
+			}
+			continue
+		}
+		if string(data) != string(ch.After) {
+			t.Errorf("%s differs from the previewed contents", ch.Path)
+		}
+	}
+}
+
+func TestFormat_unknown(t *testing.T) {
+	if _, err := Format(nil, "yaml", "", true); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestBuild_changelogSections(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: patch\ntype: fixed\n---\n\nFix probe\n")
+	writeFile(t, filepath.Join(root, ".helmver", "b.md"), "---\napi: minor\ntype: added\n---\n\nAdd HPA\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, filepath.Join(root, "api", "CHANGELOG.md"))
+	if !strings.Contains(got, "### Added\n\n- Add HPA\n\n### Fixed\n\n- Fix probe\n") {
+		t.Errorf("expected Keep a Changelog sections:\n%s", got)
+	}
+}
+
+func TestBuild_changelogTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: major\npr: 12\nauthors: [alice]\n---\n\nDrop v1\n")
+
+	cfg := &config.Config{Changelog: config.Changelog{
+		Header:   "# Release notes",
+		RepoURL:  "https://github.com/acme/charts",
+		Template: "## {{.Chart}} {{.Version}}{{if .Breaking}} (breaking){{end}}\n{{range .Changes}}\n- {{.Message}}{{if .PR}} {{prLink $.RepoURL .PR}}{{end}}{{range .Authors}} @{{.}}{{end}}{{end}}\n",
+	}}
+	plan, err := Build(Options{Dir: root, Root: root, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readFile(t, filepath.Join(root, "api", "CHANGELOG.md"))
+	want := "# Release notes\n\n## api 2.0.0 (breaking)\n\n- Drop v1 [#12](https://github.com/acme/charts/pull/12) @alice\n\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_artifactHubAnnotations(t *testing.T) {
+	root := t.TempDir()
+	chartPath := filepath.Join(root, "api", "Chart.yaml")
+	writeFile(t, chartPath, "apiVersion: v2\nname: api\nversion: 1.0.0\nannotations:\n  artifacthub.io/changes: |\n    - kind: added\n      description: Old\n  category: web\n")
+	writeFile(t, filepath.Join(root, "web", "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: patch\nweb: patch\ntype: security\npr: 5\n---\n\nPatch CVE\n")
+
+	cfg := &config.Config{Changelog: config.Changelog{RepoURL: "https://github.com/acme/charts"}}
+	plan, err := Build(Options{Dir: root, Root: root, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "apiVersion: v2\nname: api\nversion: 1.0.1\nannotations:\n" +
+		"  artifacthub.io/changes: |\n" +
+		"    - kind: security\n      description: Patch CVE\n      links:\n" +
+		"        - name: 'Pull request #5'\n          url: https://github.com/acme/charts/pull/5\n" +
+		"  category: web\n" +
+		"  artifacthub.io/containsSecurityUpdates: \"true\"\n"
+	if got := readFile(t, chartPath); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	// Without the config switch, charts lacking the annotation are left alone.
+	if got := readFile(t, filepath.Join(root, "web", "Chart.yaml")); strings.Contains(got, "annotations") {
+		t.Errorf("web should not gain annotations:\n%s", got)
+	}
+}
+
+func TestBuild_artifactHubEnabledByConfig(t *testing.T) {
+	root := t.TempDir()
+	chartPath := filepath.Join(root, "Chart.yaml")
+	writeFile(t, chartPath, "apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napp: minor\ntype: added\n---\n\nAdd PDB\n")
+
+	cfg := &config.Config{ArtifactHub: config.ArtifactHub{Changes: true}}
+	plan, err := Build(Options{Dir: root, Root: root, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "apiVersion: v2\nname: app\nversion: 1.1.0\nannotations:\n  artifacthub.io/changes: |\n    - kind: added\n      description: Add PDB\n"
+	if got := readFile(t, chartPath); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_releaseLog(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "charts", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: minor\ntype: added\n---\n\nAdd HPA\n")
+
+	cfg := &config.Config{Releases: config.Releases{Path: "RELEASES.md"}}
+	clk := clock.Fixed(time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC))
+	plan, err := Build(Options{Dir: root, Root: root, Config: cfg, Clock: clk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readFile(t, filepath.Join(root, "RELEASES.md"))
+	if !strings.HasPrefix(got, "# Releases\n\n## 2025-03-02\n") || !strings.Contains(got, "- **api** 1.0.0 -> 1.1.0 (minor)\n  - added: Add HPA\n") {
+		t.Errorf("unexpected release log:\n%s", got)
+	}
+}
+
+func TestBuild_changelogCollision(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, "api", "CHANGELOG.md"), "# Changelog\n\n## 1.1.0 (2025-01-01)\n\n### Added\n\n- Ingress\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: minor\ntype: added\n---\n\nProbes\n")
+
+	_, err := Build(Options{Dir: root, Root: root})
+	if err == nil || !strings.Contains(err.Error(), "already has an entry for this version: 1.1.0") {
+		t.Fatalf("expected a collision error, got %v", err)
+	}
+
+	plan, err := Build(Options{Dir: root, Root: root, Merge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n## 1.1.0 (2025-01-01)\n\n### Added\n\n- Ingress\n- Probes\n"
+	if got := readFile(t, filepath.Join(root, "api", "CHANGELOG.md")); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_versionInGitHistory(t *testing.T) {
+	root := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	gitRun("init")
+	gitRun("config", "user.email", "test@test.com")
+	gitRun("config", "user.name", "Test")
+	chartFile := filepath.Join(root, "api", "Chart.yaml")
+	writeFile(t, chartFile, "apiVersion: v2\nname: api\nversion: 1.1.0\n")
+	gitRun("add", "-A")
+	gitRun("commit", "-m", "release 1.1.0")
+	// Reset to an older version, as after a revert.
+	writeFile(t, chartFile, "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	gitRun("commit", "-am", "revert")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: minor\n---\n\nAgain\n")
+
+	_, err := Build(Options{Dir: root, Root: root})
+	if err == nil || !strings.Contains(err.Error(), "version 1.1.0 was already released") {
+		t.Fatalf("expected a history error, got %v", err)
+	}
+
+	plan, err := Build(Options{Dir: root, Root: root, Merge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "already released") {
+		t.Errorf("expected a history warning, got %v", plan.Warnings)
+	}
+}
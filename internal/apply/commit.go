@@ -0,0 +1,81 @@
+package apply
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jordan-simonovski/helmver/internal/git"
+)
+
+// DefaultCommitMessage is the release commit message template used by
+// apply --commit.
+const DefaultCommitMessage = `chore: apply helmver changesets
+
+{{range .Releases}}- {{.Label}}: {{.OldVersion}} -> {{.NewVersion}} ({{.Bump}})
+{{end}}`
+
+// DefaultTagPattern names release tags when no pattern is configured.
+const DefaultTagPattern = "{name}-{version}"
+
+// CommitData is the data available to a commit message template.
+type CommitData struct {
+	Releases []*Release
+}
+
+// CommitMessage renders the release commit message from a text/template
+// over CommitData. An empty text selects DefaultCommitMessage.
+func (p *Plan) CommitMessage(text string) (string, error) {
+	if text == "" {
+		text = DefaultCommitMessage
+	}
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing commit message template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, CommitData{Releases: p.Releases}); err != nil {
+		return "", fmt.Errorf("rendering commit message: %w", err)
+	}
+	msg := strings.TrimSpace(b.String())
+	if msg == "" {
+		return "", fmt.Errorf("commit message template rendered an empty message")
+	}
+	return msg + "\n", nil
+}
+
+// Paths returns every file the plan writes or deletes.
+func (p *Plan) Paths() []string {
+	paths := make([]string, 0, len(p.Changes))
+	for _, ch := range p.Changes {
+		paths = append(paths, ch.Path)
+	}
+	return paths
+}
+
+// Tag is an annotated release tag for one release.
+type Tag struct {
+	Name    string
+	Message string // "<chart> <version>" and the release's changelog entry
+	Release *Release
+}
+
+// Tags returns the release tag of every release, named by pattern. Charts
+// that share a name would get the same tag, which is an error.
+func (p *Plan) Tags(pattern git.TagPattern) ([]Tag, error) {
+	tags := make([]Tag, 0, len(p.Releases))
+	seen := make(map[string]string)
+	for _, rel := range p.Releases {
+		name := pattern.Format(rel.Chart.Name, rel.NewVersion)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s would both be tagged %s", other, rel.Label, name)
+		}
+		seen[name] = rel.Label
+		msg := rel.Chart.Name + " " + rel.NewVersion + "\n"
+		if rel.Notes != "" {
+			msg += "\n" + rel.Notes + "\n"
+		}
+		tags = append(tags, Tag{Name: name, Message: msg, Release: rel})
+	}
+	return tags, nil
+}
@@ -0,0 +1,73 @@
+package apply
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jordan-simonovski/helmver/internal/clock"
+	"github.com/jordan-simonovski/helmver/internal/git"
+)
+
+func TestPlan_commitAndTags(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, "web", "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 0.1.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\napi: minor\ntype: added\nweb: patch\n---\n\nAdd HPA\n")
+
+	clk := clock.Fixed(time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC))
+	plan, err := Build(Options{Dir: root, Root: root, Clock: clk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := plan.CommitMessage("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "chore: apply helmver changesets\n\n- api: 1.0.0 -> 1.1.0 (minor)\n- web: 0.1.0 -> 0.1.1 (patch)\n"
+	if msg != want {
+		t.Errorf("default message:\n%s\nwant:\n%s", msg, want)
+	}
+	msg, err = plan.CommitMessage("release{{range .Releases}} {{.Chart.Name}}@{{.NewVersion}}{{end}} [skip ci]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "release api@1.1.0 web@0.1.1 [skip ci]\n" {
+		t.Errorf("templated message = %q", msg)
+	}
+	if _, err := plan.CommitMessage("{{.Missing}}"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	tags, err := plan.Tags(git.TagPattern(DefaultTagPattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags[0].Name != "api-1.1.0" || tags[1].Name != "web-0.1.1" {
+		t.Fatalf("tags = %+v", tags)
+	}
+	if want := "api 1.1.0\n\n### Added\n\n- Add HPA\n"; tags[0].Message != want {
+		t.Errorf("api tag message:\n%s\nwant:\n%s", tags[0].Message, want)
+	}
+
+	// Two Chart.yaml files, two CHANGELOG.md files and the changeset.
+	if got := plan.Paths(); len(got) != 5 {
+		t.Errorf("paths = %v", got)
+	}
+}
+
+func TestPlan_tagCollision(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, "b", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(root, ".helmver", "a.md"), "---\na/api: patch\nb/api: patch\n---\n\nFix\n")
+
+	plan, err := Build(Options{Dir: root, Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.Tags(git.TagPattern(DefaultTagPattern)); err == nil {
+		t.Error("expected an error for two charts tagged api-1.0.1")
+	}
+}
@@ -0,0 +1,132 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Execute writes every planned change. New contents are first written to
+// temporary files next to their targets and then renamed into place; if
+// any step fails, files already replaced are restored to their original
+// contents and the error is returned. Deletions (consumed changesets) run
+// last, once every write has succeeded.
+func (p *Plan) Execute() error {
+	var writes, deletes []FileChange
+	for _, ch := range p.Changes {
+		if ch.After == nil {
+			deletes = append(deletes, ch)
+		} else {
+			writes = append(writes, ch)
+		}
+	}
+
+	temps := make([]string, len(writes))
+	cleanup := func() {
+		for _, t := range temps {
+			if t != "" {
+				_ = os.Remove(t)
+			}
+		}
+	}
+
+	for i, ch := range writes {
+		tmp, err := writeTemp(ch.Path, ch.After)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("writing %s: %w", ch.Path, err)
+		}
+		temps[i] = tmp
+	}
+
+	for i, ch := range writes {
+		if err := os.Rename(temps[i], ch.Path); err != nil {
+			cleanup()
+			if rerr := restore(writes[:i]); rerr != nil {
+				return fmt.Errorf("writing %s: %w (rollback failed: %v)", ch.Path, err, rerr)
+			}
+			return fmt.Errorf("writing %s: %w", ch.Path, err)
+		}
+		temps[i] = ""
+	}
+
+	for i, ch := range deletes {
+		if err := os.Remove(ch.Path); err != nil && !os.IsNotExist(err) {
+			if rerr := restore(append(append([]FileChange(nil), writes...), deletes[:i]...)); rerr != nil {
+				return fmt.Errorf("removing %s: %w (rollback failed: %v)", ch.Path, err, rerr)
+			}
+			return fmt.Errorf("removing %s: %w", ch.Path, err)
+		}
+	}
+	return nil
+}
+
+// restore puts back the original contents of already-applied changes,
+// deleting files that did not exist before.
+func restore(applied []FileChange) error {
+	var firstErr error
+	for i := len(applied) - 1; i >= 0; i-- {
+		ch := applied[i]
+		var err error
+		if ch.Before == nil {
+			err = os.Remove(ch.Path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = WriteFileAtomic(ch.Path, ch.Before)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteFileAtomic replaces path with data via a temporary file and rename,
+// so readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := writeTemp(path, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes data to a new temporary file in path's directory, with
+// path's current permissions (0644 for new files), and returns its name.
+func writeTemp(path string, data []byte) (string, error) {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".helmver-*")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(name)
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(name)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	if err := os.Chmod(name, mode); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
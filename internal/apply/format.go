@@ -0,0 +1,175 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordan-simonovski/helmver/internal/diff"
+)
+
+// Format renders a plan as text (a release summary followed by unified
+// diffs of every file change) or json. Paths are shown relative to root.
+// A nil plan renders as an empty one.
+func Format(plan *Plan, format, root string, dryRun bool) (string, error) {
+	if plan == nil {
+		plan = &Plan{}
+	}
+	switch format {
+	case "text":
+		return formatText(plan, root), nil
+	case "json":
+		return formatJSON(plan, root, dryRun)
+	default:
+		return "", fmt.Errorf("unknown format %q (use text or json)", format)
+	}
+}
+
+// Diff returns the unified diff of one file change, or "" if it changes
+// nothing.
+func (ch FileChange) Diff(root string) string {
+	rel := relPath(root, ch.Path)
+	return diff.Unified("a/"+rel, "b/"+rel, ch.Before, ch.After)
+}
+
+// Action describes a file change as "create", "modify" or "delete".
+func (ch FileChange) Action() string {
+	switch {
+	case ch.Before == nil:
+		return "create"
+	case ch.After == nil:
+		return "delete"
+	}
+	return "modify"
+}
+
+func formatText(plan *Plan, root string) string {
+	var b strings.Builder
+	for _, rel := range plan.Releases {
+		fmt.Fprintf(&b, "  %s: %s -> %s (%s)", rel.Label, rel.OldVersion, rel.NewVersion, rel.Bump)
+		if rel.NewAppVersion != "" {
+			fmt.Fprintf(&b, ", appVersion %s -> %s", DisplayVersion(rel.OldAppVersion), rel.NewAppVersion)
+		}
+		b.WriteString("\n")
+	}
+	for _, ch := range plan.Changes {
+		if d := ch.Diff(root); d != "" {
+			b.WriteString("\n")
+			b.WriteString(d)
+		}
+	}
+	if len(plan.Consumed) > 0 {
+		b.WriteString("\nchangesets to consume:\n")
+		for _, path := range plan.Consumed {
+			fmt.Fprintf(&b, "  %s\n", relPath(root, path))
+		}
+	}
+	if len(plan.Kept) > 0 {
+		b.WriteString("\nchangesets skipped and kept (unknown charts):\n")
+		for _, path := range plan.Kept {
+			fmt.Fprintf(&b, "  %s\n", relPath(root, path))
+		}
+	}
+	return b.String()
+}
+
+type jsonRelease struct {
+	Chart         string              `json:"chart"`
+	ID            string              `json:"id"`
+	Bump          string              `json:"bump"`
+	OldVersion    string              `json:"oldVersion"`
+	NewVersion    string              `json:"newVersion"`
+	OldAppVersion string              `json:"oldAppVersion,omitempty"`
+	NewAppVersion string              `json:"newAppVersion,omitempty"`
+	Changes       []jsonReleaseChange `json:"changes"`
+}
+
+type jsonReleaseChange struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message"`
+}
+
+type jsonChange struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Diff   string `json:"diff"`
+}
+
+func formatJSON(plan *Plan, root string, dryRun bool) (string, error) {
+	payload := struct {
+		DryRun   bool          `json:"dryRun"`
+		Releases []jsonRelease `json:"releases"`
+		Changes  []jsonChange  `json:"changes"`
+		Consumed []string      `json:"consumed"`
+		Kept     []string      `json:"kept"`
+		Warnings []string      `json:"warnings"`
+	}{
+		DryRun:   dryRun,
+		Releases: []jsonRelease{},
+		Changes:  []jsonChange{},
+		Consumed: relPaths(root, plan.Consumed),
+		Kept:     relPaths(root, plan.Kept),
+		Warnings: append([]string{}, plan.Warnings...),
+	}
+	for _, rel := range plan.Releases {
+		payload.Releases = append(payload.Releases, jsonRelease{
+			Chart:         rel.Label,
+			ID:            rel.Chart.ID,
+			Bump:          rel.Bump,
+			OldVersion:    rel.OldVersion,
+			NewVersion:    rel.NewVersion,
+			OldAppVersion: rel.OldAppVersion,
+			NewAppVersion: rel.NewAppVersion,
+			Changes:       releaseChanges(rel),
+		})
+	}
+	for _, ch := range plan.Changes {
+		payload.Changes = append(payload.Changes, jsonChange{
+			Path:   relPath(root, ch.Path),
+			Action: ch.Action(),
+			Diff:   ch.Diff(root),
+		})
+	}
+
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// DisplayVersion formats a version for output, showing an unset one, such
+// as a chart without an appVersion, as "(none)".
+func DisplayVersion(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
+
+func relPaths(root string, paths []string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		out = append(out, relPath(root, p))
+	}
+	return out
+}
+
+func relPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func releaseChanges(rel *Release) []jsonReleaseChange {
+	out := make([]jsonReleaseChange, 0, len(rel.Changes))
+	for _, c := range rel.Changes {
+		if c.Message != "" {
+			out = append(out, jsonReleaseChange{Type: c.Type, Message: c.Message})
+		}
+	}
+	return out
+}
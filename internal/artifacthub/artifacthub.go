@@ -0,0 +1,111 @@
+// Package artifacthub renders the Artifact Hub annotations that describe a
+// chart release (https://artifacthub.io/docs/topics/annotations/helm/).
+package artifacthub
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jordan-simonovski/helmver/internal/changelog"
+)
+
+// Annotation keys written by helmver.
+const (
+	ChangesKey  = "artifacthub.io/changes"
+	SecurityKey = "artifacthub.io/containsSecurityUpdates"
+)
+
+// Change is one entry of the artifacthub.io/changes annotation.
+type Change struct {
+	Kind        string `yaml:"kind"`
+	Description string `yaml:"description"`
+	Links       []Link `yaml:"links,omitempty"`
+}
+
+// Link is a named URL attached to a change.
+type Link struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+var refNumber = regexp.MustCompile(`^#?(\d+)$`)
+
+// FromChangelog converts changelog changes to Artifact Hub changes. The
+// change type becomes the kind (untyped changes are "changed"); issues and
+// the PR become links when they are URLs, or numbers and repoURL is set.
+func FromChangelog(changes []changelog.Change, repoURL string) []Change {
+	var out []Change
+	for _, c := range changes {
+		msg := strings.TrimSpace(c.Message)
+		if msg == "" {
+			continue
+		}
+		kind := c.Type
+		if kind == "" {
+			kind = "changed"
+		}
+		ch := Change{Kind: kind, Description: msg}
+		for _, issue := range c.Issues {
+			if l, ok := link("Issue", "issues", issue, repoURL); ok {
+				ch.Links = append(ch.Links, l)
+			}
+		}
+		if c.PR != "" {
+			if l, ok := link("Pull request", "pull", c.PR, repoURL); ok {
+				ch.Links = append(ch.Links, l)
+			}
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+// ContainsSecurity reports whether any change has the security kind.
+func ContainsSecurity(changes []Change) bool {
+	for _, c := range changes {
+		if c.Kind == "security" {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the artifacthub.io/changes annotation value: a YAML list
+// of changes.
+func Render(changes []Change) (string, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(changes); err != nil {
+		return "", fmt.Errorf("encoding artifacthub.io/changes: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func link(label, kind, ref, repoURL string) (Link, bool) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+		name := label
+		if i := strings.LastIndex(strings.TrimRight(ref, "/"), "/"); i >= 0 {
+			if m := refNumber.FindStringSubmatch(strings.TrimRight(ref, "/")[i+1:]); m != nil {
+				name += " #" + m[1]
+			}
+		}
+		return Link{Name: name, URL: ref}, true
+	}
+	m := refNumber.FindStringSubmatch(ref)
+	if m == nil || repoURL == "" {
+		return Link{}, false
+	}
+	return Link{
+		Name: label + " #" + m[1],
+		URL:  fmt.Sprintf("%s/%s/%s", strings.TrimRight(repoURL, "/"), kind, m[1]),
+	}, true
+}
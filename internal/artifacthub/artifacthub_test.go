@@ -0,0 +1,51 @@
+package artifacthub
+
+import (
+	"testing"
+
+	"github.com/jordan-simonovski/helmver/internal/changelog"
+)
+
+func TestRender(t *testing.T) {
+	changes := FromChangelog([]changelog.Change{
+		{Type: "added", Message: "Add HPA", PR: "#41", Issues: []string{"12", "https://tracker.example.com/browse/13"}},
+		{Message: "Updated dependency common to 1.3.0\n"},
+		{Type: "security", Message: "Bump base image", Issues: []string{"JIRA-9"}},
+		{Type: "fixed", Message: ""},
+	}, "https://github.com/acme/charts/")
+
+	got, err := Render(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `- kind: added
+  description: Add HPA
+  links:
+    - name: 'Issue #12'
+      url: https://github.com/acme/charts/issues/12
+    - name: 'Issue #13'
+      url: https://tracker.example.com/browse/13
+    - name: 'Pull request #41'
+      url: https://github.com/acme/charts/pull/41
+- kind: changed
+  description: Updated dependency common to 1.3.0
+- kind: security
+  description: Bump base image
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if !ContainsSecurity(changes) {
+		t.Error("expected ContainsSecurity to be true")
+	}
+}
+
+func TestFromChangelog_noRepoURL(t *testing.T) {
+	changes := FromChangelog([]changelog.Change{{Type: "fixed", Message: "Fix", PR: "7"}}, "")
+	if len(changes) != 1 || len(changes[0].Links) != 0 {
+		t.Errorf("numeric refs need a repoURL, got %+v", changes)
+	}
+	if ContainsSecurity(changes) {
+		t.Error("expected ContainsSecurity to be false")
+	}
+}
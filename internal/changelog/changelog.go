@@ -1,31 +1,34 @@
 package changelog
 
 import (
-	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
-const header = "# Changelog\n"
-
 // Prepend adds a new version entry to the top of CHANGELOG.md in the given directory.
 // If CHANGELOG.md does not exist, it is created with a top-level heading.
 func Prepend(dir, version, message string) error {
-	path := filepath.Join(dir, "CHANGELOG.md")
-	date := time.Now().Format("2006-01-02")
+	return Default.Prepend(dir, Entry{Version: version, Changes: []Change{{Message: message}}})
+}
 
-	entry := fmt.Sprintf("## %s (%s)\n\n%s\n", version, date, strings.TrimRight(message, "\n"))
+// Path returns the CHANGELOG.md path for a chart directory.
+func Path(dir string) string {
+	return filepath.Join(dir, "CHANGELOG.md")
+}
+
+// Render returns the CHANGELOG.md content with a new version entry in the
+// default format inserted after the top-level heading. A nil existing means
+// the file does not exist yet, and it is created with a top-level heading.
+func Render(existing []byte, version, message string) []byte {
+	out, _ := Default.Render(existing, Entry{Version: version, Changes: []Change{{Message: message}}})
+	return out
+}
 
-	existing, err := os.ReadFile(path)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("reading %s: %w", path, err)
-		}
-		// File does not exist; create with header
-		content := header + "\n" + entry + "\n"
-		return os.WriteFile(path, []byte(content), 0o644)
+// insert places entry after the first line of existing when that line is a
+// top-level heading; otherwise header and entry are prepended.
+func insert(existing []byte, header, entry string) []byte {
+	if existing == nil {
+		return []byte(header + "\n" + entry + "\n")
 	}
 
 	// Insert the new entry after the top-level heading
@@ -40,5 +43,5 @@ func Prepend(dir, version, message string) error {
 		content = header + "\n" + entry + "\n" + content
 	}
 
-	return os.WriteFile(path, []byte(content), 0o644)
+	return []byte(content)
 }
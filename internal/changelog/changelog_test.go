@@ -1,6 +1,7 @@
 package changelog
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -93,3 +94,222 @@ func TestPrependMultilineMessage(t *testing.T) {
 		t.Errorf("multiline message not preserved in:\n%s", s)
 	}
 }
+
+func TestBody_untyped(t *testing.T) {
+	got := Body([]Change{{Message: "First\n"}, {Message: ""}, {Message: "Second"}})
+	if got != "First\n\nSecond" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBody_sections(t *testing.T) {
+	got := Body([]Change{
+		{Type: "fixed", Message: "Fix probe timeout"},
+		{Type: "added", Message: "Add HPA support\nwith custom metrics"},
+		{Type: "security", Message: "Bump base image"},
+		{Message: "Updated dependency common to 1.3.0"},
+		{Type: "fixed", Message: "Fix service port"},
+	})
+	want := `### Added
+
+- Add HPA support
+  with custom metrics
+
+### Changed
+
+- Updated dependency common to 1.3.0
+
+### Fixed
+
+- Fix probe timeout
+- Fix service port
+
+### Security
+
+- Bump base image`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_sections(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	existing := []byte("# Changelog\n\n## 0.1.0 (2025-01-01)\n\nOld entry\n")
+	got := string(Render(existing, "0.2.0", Body([]Change{{Type: "added", Message: "New feature"}})))
+	want := "# Changelog\n\n## 0.2.0 (" + today + ")\n\n### Added\n\n- New feature\n\n\n## 0.1.0 (2025-01-01)\n\nOld entry\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderer_defaultMatchesRender(t *testing.T) {
+	existing := []byte("# Changelog\n\n## 0.1.0 (2025-01-01)\n\nOld entry\n")
+	want := Render(existing, "0.2.0", "New feature")
+	got, err := Default.Render(existing, Entry{Chart: "api", Version: "0.2.0", Bump: "minor", Changes: []Change{{Message: "New feature"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEntry_Sections(t *testing.T) {
+	untyped := Entry{Changes: []Change{{Message: "One"}, {Message: "Two"}}}
+	if s := untyped.Sections(); len(s) != 1 || s[0].Title != "" || len(s[0].Changes) != 2 {
+		t.Errorf("untyped changes should form one untitled section, got %+v", s)
+	}
+
+	// Like Body, untyped changes go under Changed once any change is typed.
+	mixed := Entry{Changes: []Change{{Type: "fixed", Message: "Fix"}, {Message: "Bump dependency"}}}
+	s := mixed.Sections()
+	if len(s) != 2 || s[0].Type != "changed" || s[0].Changes[0].Message != "Bump dependency" || s[1].Type != "fixed" {
+		t.Errorf("unexpected sections: %+v", s)
+	}
+}
+
+func TestRenderer_customTemplate(t *testing.T) {
+	tmpl := `## [{{.Version}}] - {{.Date}}
+{{if .Breaking}}
+> **Breaking changes:** {{.Chart}} {{.PreviousVersion}} -> {{.Version}}
+{{end}}{{range .Sections}}{{if .Title}}
+### {{.Title}}
+{{end}}{{range .Changes}}
+- {{indent 2 .Message}}{{if .PR}} ({{prLink $.RepoURL .PR}}){{end}}{{range .Authors}} @{{.}}{{end}}{{end}}
+{{end}}`
+	r, err := NewRenderer(tmpl, "# Release notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.Render(nil, Entry{
+		Chart:           "api",
+		Version:         "2.0.0",
+		PreviousVersion: "1.4.0",
+		Bump:            "major",
+		Date:            "2025-03-01",
+		RepoURL:         "https://github.com/acme/charts/",
+		Changes: []Change{
+			{Type: "removed", Message: "Drop v1 API", PR: "#41", Authors: []string{"alice"}},
+			{Message: "Updated dependency common to 1.3.0"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Release notes
+
+## [2.0.0] - 2025-03-01
+
+> **Breaking changes:** api 1.4.0 -> 2.0.0
+
+### Changed
+
+- Updated dependency common to 1.3.0
+
+### Removed
+
+- Drop v1 API ([#41](https://github.com/acme/charts/pull/41)) @alice
+
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNewRenderer_errors(t *testing.T) {
+	if _, err := NewRenderer("{{.Version", ""); err == nil {
+		t.Error("expected a parse error")
+	}
+	r, err := NewRenderer("{{.Nope}}", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Entry(Entry{Version: "1.0.0"}); err == nil {
+		t.Error("expected an execution error for an unknown field")
+	}
+}
+
+func TestRendererMerge_sections(t *testing.T) {
+	existing := []byte(`# Changelog
+
+## 1.3.0 (2025-01-02)
+
+### Added
+
+- Ingress support
+
+## 1.2.0 (2025-01-01)
+
+Old entry
+`)
+	got, err := Default.Merge(existing, Entry{
+		Version: "1.3.0",
+		Date:    "2025-02-01",
+		Changes: []Change{
+			{Type: "added", Message: "Probes"},
+			{Type: "fixed", Message: "Service port"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Changelog
+
+## 1.3.0 (2025-01-02)
+
+### Added
+
+- Ingress support
+- Probes
+
+### Fixed
+
+- Service port
+
+## 1.2.0 (2025-01-01)
+
+Old entry
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRendererMerge_untyped(t *testing.T) {
+	existing := []byte("# Changelog\r\n\r\n## 1.3.0 (2025-01-02)\r\n\r\nFirst note\r\n")
+	got, err := Default.Merge(existing, Entry{Version: "1.3.0", Changes: []Change{{Message: "Second note"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\r\n\r\n## 1.3.0 (2025-01-02)\r\n\r\nFirst note\r\n\r\nSecond note\r\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRendererMerge_notFound(t *testing.T) {
+	_, err := Default.Merge([]byte("# Changelog\n\n## 1.2.0\n\nOld\n"), Entry{Version: "1.3.0"})
+	if !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("err = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestPrependExistingVersion(t *testing.T) {
+	dir := t.TempDir()
+	existing := "# Changelog\n\n## 0.2.0 (2025-01-01)\n\nNew feature\n"
+	if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Prepend(dir, "0.2.0", "New feature"); !errors.Is(err, ErrVersionExists) {
+		t.Fatalf("err = %v, want ErrVersionExists", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != existing {
+		t.Errorf("changelog was modified:\n%s", data)
+	}
+}
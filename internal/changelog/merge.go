@@ -0,0 +1,193 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Merge adds e's notes to the existing release with the same version
+// instead of inserting a second heading for it. Items under a "### "
+// section are appended to the matching section of the existing release;
+// sections it does not have yet, and untyped notes, are added to it. The
+// existing heading (and its date) is kept. It returns ErrVersionNotFound
+// when existing has no release for e.Version.
+func (r *Renderer) Merge(existing []byte, e Entry) ([]byte, error) {
+	rendered, err := r.Entry(e)
+	if err != nil {
+		return nil, err
+	}
+	// The rendered entry's own heading is dropped; only its body is merged.
+	newLines := strings.Split(strings.ReplaceAll(rendered, "\r\n", "\n"), "\n")
+	for i, line := range newLines {
+		if strings.HasPrefix(line, "## ") {
+			newLines = newLines[i+1:]
+			break
+		}
+	}
+	notes, sections := blocks(newLines)
+
+	eol := "\n"
+	if strings.Contains(string(existing), "\r\n") {
+		eol = "\r\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(string(existing), "\r\n", "\n"), "\n")
+	start, end := releaseSpan(lines, e.Version)
+	if start < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, e.Version)
+	}
+	_, oldSections := blocks(lines[start+1 : end])
+
+	type insertion struct {
+		at    int
+		lines []string
+	}
+	var ins []insertion
+	// Line numbers from blocks are relative to the release body.
+	offset := start + 1
+
+	notesEnd := end
+	if len(oldSections) > 0 {
+		notesEnd = offset + oldSections[0].start
+	}
+	if len(notes.body) > 0 {
+		at := lastContent(lines, start, notesEnd)
+		ins = append(ins, insertion{at: at, lines: append([]string{""}, notes.body...)})
+	}
+
+	tail := lastContent(lines, start, end)
+	var appended []string
+	for _, s := range sections {
+		if len(s.body) == 0 {
+			continue
+		}
+		if old, ok := findBlock(oldSections, s.title); ok {
+			at := lastContent(lines, offset+old.start, offset+old.end)
+			add := s.body
+			if !isListItem(lines[at-1]) || !isListItem(add[0]) {
+				add = append([]string{""}, add...)
+			}
+			ins = append(ins, insertion{at: at, lines: add})
+			continue
+		}
+		appended = append(appended, "", "### "+s.title, "")
+		appended = append(appended, s.body...)
+	}
+	if len(appended) > 0 {
+		ins = append(ins, insertion{at: tail, lines: appended})
+	}
+	if len(ins) == 0 {
+		return existing, nil
+	}
+	// Insert bottom-up so earlier line numbers stay valid; insertions at the
+	// same line keep their order.
+	sort.SliceStable(ins, func(i, j int) bool { return ins[i].at > ins[j].at })
+	for i := 0; i < len(ins); {
+		j := i
+		var add []string
+		for j < len(ins) && ins[j].at == ins[i].at {
+			j++
+		}
+		for k := j - 1; k >= i; k-- {
+			add = append(append([]string(nil), ins[k].lines...), add...)
+		}
+		at := ins[i].at
+		lines = append(lines[:at], append(add, lines[at:]...)...)
+		i = j
+	}
+	return []byte(strings.Join(lines, eol)), nil
+}
+
+// block is a run of release body lines: the notes before the first
+// section, or one "### " section. start and end are line numbers relative
+// to the release body, with end exclusive; body is the trimmed content.
+type block struct {
+	title      string
+	start, end int
+	body       []string
+}
+
+// blocks splits release body lines into its notes and sections.
+func blocks(lines []string) (block, []block) {
+	notes := block{start: 0, end: len(lines)}
+	var sections []block
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if inFence || !strings.HasPrefix(line, "### ") {
+			continue
+		}
+		if len(sections) == 0 {
+			notes.end = i
+		} else {
+			sections[len(sections)-1].end = i
+		}
+		sections = append(sections, block{title: strings.TrimSpace(line[4:]), start: i, end: len(lines)})
+	}
+	notes.body = trimLines(lines[notes.start:notes.end])
+	for i := range sections {
+		s := &sections[i]
+		s.body = trimLines(lines[s.start+1 : s.end])
+	}
+	return notes, sections
+}
+
+func findBlock(bs []block, title string) (block, bool) {
+	for _, b := range bs {
+		if strings.EqualFold(b.title, title) {
+			return b, true
+		}
+	}
+	return block{}, false
+}
+
+// releaseSpan returns the line of version's "## " heading and the line
+// where its release ends, or -1 when there is no such release.
+func releaseSpan(lines []string, version string) (start, end int) {
+	want := strings.TrimPrefix(version, "v")
+	start = -1
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if inFence || !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		if start >= 0 {
+			return start, i
+		}
+		if parseHeading(strings.TrimSpace(line[3:])).Version == want {
+			start = i
+		}
+	}
+	return start, len(lines)
+}
+
+// lastContent returns the line after the last non-blank line in
+// lines[from:to], where lines[from] is a heading.
+func lastContent(lines []string, from, to int) int {
+	for i := to - 1; i > from; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i + 1
+		}
+	}
+	return from + 1
+}
+
+// trimLines drops leading and trailing blank lines.
+func trimLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
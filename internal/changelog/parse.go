@@ -0,0 +1,198 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrVersionNotFound is returned by Document.Find when no release matches.
+var ErrVersionNotFound = errors.New("version not found in changelog")
+
+// ErrVersionExists is returned when an entry would duplicate a release
+// that the changelog already has.
+var ErrVersionExists = errors.New("changelog already has an entry for this version")
+
+// Document is a parsed CHANGELOG.md.
+type Document struct {
+	Preamble string    // everything before the first release heading
+	Releases []Release // newest first, in file order
+}
+
+// Release is one "## <version>" entry of a changelog.
+type Release struct {
+	Version  string // without a leading "v" or brackets
+	Date     string // YYYY-MM-DD, or empty if the heading has no date
+	Heading  string // the heading line as written, without "## "
+	Body     string // everything below the heading, trimmed
+	Notes    string // text before the first "### " section, trimmed
+	Sections []ReleaseSection
+}
+
+// ReleaseSection is a "### <title>" block inside a release.
+type ReleaseSection struct {
+	Title string   // e.g. "Added"
+	Type  string   // the matching change type (e.g. "added"), or empty
+	Body  string   // the section text, trimmed
+	Items []string // top-level list items, with continuation lines joined by "\n"
+}
+
+var (
+	// releaseHeading matches "## 1.2.0 (2025-01-01)", "## [1.2.0] - 2025-01-01",
+	// "## v1.2.0" and similar.
+	releaseHeading = regexp.MustCompile(`^\[?v?([0-9A-Za-z][0-9A-Za-z.+\-]*)\]?(.*)$`)
+	isoDate        = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+)
+
+// ParseFile reads and parses the CHANGELOG.md in a chart directory.
+func ParseFile(dir string) (*Document, error) {
+	data, err := os.ReadFile(Path(dir))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Parse splits a changelog into releases. Every level-two heading starts a
+// release; headings inside fenced code blocks are ignored. Both helmver's
+// own formats and Keep a Changelog style headings are understood.
+func Parse(data []byte) *Document {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	doc := &Document{}
+	var preamble []string
+	var cur *Release
+	var body []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			cur.Notes, cur.Sections = parseSections(body)
+			doc.Releases = append(doc.Releases, *cur)
+		}
+	}
+
+	inFence := false
+	for _, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "## ") {
+			flush()
+			cur = parseHeading(strings.TrimSpace(line[3:]))
+			body = nil
+			continue
+		}
+		if cur == nil {
+			preamble = append(preamble, line)
+		} else {
+			body = append(body, line)
+		}
+	}
+	flush()
+	doc.Preamble = strings.TrimSpace(strings.Join(preamble, "\n"))
+	return doc
+}
+
+// Find returns the release with the given version; a leading "v" is
+// ignored. An empty version returns the newest release.
+func (d *Document) Find(version string) (*Release, error) {
+	if version == "" {
+		if len(d.Releases) == 0 {
+			return nil, fmt.Errorf("%w: changelog has no releases", ErrVersionNotFound)
+		}
+		return &d.Releases[0], nil
+	}
+	want := strings.TrimPrefix(version, "v")
+	for i := range d.Releases {
+		if d.Releases[i].Version == want {
+			return &d.Releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+}
+
+func parseHeading(heading string) *Release {
+	r := &Release{Heading: heading, Version: heading}
+	if m := releaseHeading.FindStringSubmatch(heading); m != nil {
+		r.Version = m[1]
+		r.Date = isoDate.FindString(m[2])
+	}
+	return r
+}
+
+func parseSections(body []string) (string, []ReleaseSection) {
+	var notes []string
+	var sections []ReleaseSection
+	var cur *ReleaseSection
+	var lines []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(lines, "\n"))
+			cur.Items = listItems(lines)
+			sections = append(sections, *cur)
+		}
+	}
+
+	inFence := false
+	for _, line := range body {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "### ") {
+			flush()
+			title := strings.TrimSpace(line[4:])
+			cur = &ReleaseSection{Title: title, Type: typeForTitle(title)}
+			lines = nil
+			continue
+		}
+		if cur == nil {
+			notes = append(notes, line)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return strings.TrimSpace(strings.Join(notes, "\n")), sections
+}
+
+// listItems returns the top-level "- " or "* " items of a markdown list.
+// Indented lines continue the previous item.
+func listItems(lines []string) []string {
+	var items []string
+	var cur []string
+	flush := func() {
+		if cur != nil {
+			items = append(items, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flush()
+			cur = []string{strings.TrimSpace(line[2:])}
+		case cur != nil && strings.HasPrefix(line, "  "):
+			cur = append(cur, strings.TrimSpace(line))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return items
+}
+
+func typeForTitle(title string) string {
+	for _, s := range sections {
+		if strings.EqualFold(s.title, title) {
+			return s.typ
+		}
+	}
+	return ""
+}
+
+func isFence(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+}
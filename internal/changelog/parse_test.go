@@ -0,0 +1,99 @@
+package changelog
+
+import (
+	"errors"
+	"testing"
+)
+
+const sample = "# Changelog\r\n" +
+	"\r\n" +
+	"## 1.3.0 (2025-03-02)\r\n" +
+	"\r\n" +
+	"### Added\r\n" +
+	"\r\n" +
+	"- Add HPA support\r\n" +
+	"  with custom metrics\r\n" +
+	"- Add PDB\r\n" +
+	"\r\n" +
+	"### Fixed\r\n" +
+	"\r\n" +
+	"- Fix probe timeout\r\n" +
+	"\r\n" +
+	"\r\n" +
+	"## [1.2.0] - 2025-01-10\r\n" +
+	"\r\n" +
+	"Plain message\r\n" +
+	"\r\n" +
+	"```markdown\r\n" +
+	"## not a release\r\n" +
+	"```\r\n" +
+	"\r\n" +
+	"## v1.1.0\r\n" +
+	"\r\n" +
+	"Initial\r\n"
+
+func TestParse(t *testing.T) {
+	doc := Parse([]byte(sample))
+	if doc.Preamble != "# Changelog" {
+		t.Errorf("preamble: got %q", doc.Preamble)
+	}
+	if len(doc.Releases) != 3 {
+		t.Fatalf("expected 3 releases, got %d: %+v", len(doc.Releases), doc.Releases)
+	}
+
+	r := doc.Releases[0]
+	if r.Version != "1.3.0" || r.Date != "2025-03-02" || r.Notes != "" {
+		t.Errorf("release 0: got %+v", r)
+	}
+	if len(r.Sections) != 2 || r.Sections[0].Type != "added" || r.Sections[1].Title != "Fixed" {
+		t.Fatalf("sections: got %+v", r.Sections)
+	}
+	items := r.Sections[0].Items
+	if len(items) != 2 || items[0] != "Add HPA support\nwith custom metrics" || items[1] != "Add PDB" {
+		t.Errorf("items: got %q", items)
+	}
+
+	r = doc.Releases[1]
+	if r.Version != "1.2.0" || r.Date != "2025-01-10" || len(r.Sections) != 0 {
+		t.Errorf("release 1: got %+v", r)
+	}
+	if r.Body != "Plain message\n\n```markdown\n## not a release\n```" {
+		t.Errorf("release 1 body: got %q", r.Body)
+	}
+
+	r = doc.Releases[2]
+	if r.Version != "1.1.0" || r.Date != "" || r.Body != "Initial" {
+		t.Errorf("release 2: got %+v", r)
+	}
+}
+
+func TestParse_roundTripsRender(t *testing.T) {
+	data := Render(nil, "0.1.0", "First")
+	data = Render(data, "0.2.0", Body([]Change{{Type: "fixed", Message: "Second"}}))
+
+	doc := Parse(data)
+	if len(doc.Releases) != 2 || doc.Releases[0].Version != "0.2.0" || doc.Releases[1].Body != "First" {
+		t.Fatalf("unexpected releases: %+v", doc.Releases)
+	}
+	if s := doc.Releases[0].Sections; len(s) != 1 || s[0].Items[0] != "Second" {
+		t.Errorf("unexpected sections: %+v", s)
+	}
+}
+
+func TestDocument_Find(t *testing.T) {
+	doc := Parse([]byte(sample))
+	r, err := doc.Find("")
+	if err != nil || r.Version != "1.3.0" {
+		t.Errorf("latest: got %+v, %v", r, err)
+	}
+	r, err = doc.Find("v1.2.0")
+	if err != nil || r.Version != "1.2.0" {
+		t.Errorf("v1.2.0: got %+v, %v", r, err)
+	}
+	if _, err := doc.Find("9.9.9"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound, got %v", err)
+	}
+	if _, err := Parse(nil).Find(""); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound for an empty changelog, got %v", err)
+	}
+}
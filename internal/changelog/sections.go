@@ -0,0 +1,79 @@
+package changelog
+
+import "strings"
+
+// Change is one line item of a release entry. Type is a Keep a Changelog
+// change type ("added", "fixed", ...) or empty.
+type Change struct {
+	Type    string
+	Message string
+	Issues  []string
+	PR      string
+	Authors []string
+}
+
+// sections lists the Keep a Changelog section for each change type, in
+// the order they are written.
+var sections = []struct {
+	typ, title string
+}{
+	{"added", "Added"},
+	{"changed", "Changed"},
+	{"deprecated", "Deprecated"},
+	{"removed", "Removed"},
+	{"fixed", "Fixed"},
+	{"security", "Security"},
+}
+
+// Body renders the text of a release entry. When no change has a type the
+// messages are joined with blank lines, as helmver always wrote them.
+// Otherwise the messages are grouped into Keep a Changelog sections
+// ("### Added", "### Fixed", ...) as list items, and untyped messages
+// (such as dependency updates) go under "### Changed".
+func Body(changes []Change) string {
+	typed := false
+	for _, c := range changes {
+		if c.Type != "" {
+			typed = true
+			break
+		}
+	}
+
+	if !typed {
+		messages := make([]string, 0, len(changes))
+		for _, c := range changes {
+			if m := strings.TrimSpace(c.Message); m != "" {
+				messages = append(messages, m)
+			}
+		}
+		return strings.Join(messages, "\n\n")
+	}
+
+	byType := make(map[string][]string)
+	for _, c := range changes {
+		m := strings.TrimSpace(c.Message)
+		if m == "" {
+			continue
+		}
+		typ := c.Type
+		if typ == "" {
+			typ = "changed"
+		}
+		byType[typ] = append(byType[typ], m)
+	}
+
+	var blocks []string
+	for _, s := range sections {
+		if len(byType[s.typ]) == 0 {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString("### " + s.title + "\n")
+		for _, m := range byType[s.typ] {
+			b.WriteString("\n- ")
+			b.WriteString(strings.ReplaceAll(m, "\n", "\n  "))
+		}
+		blocks = append(blocks, b.String())
+	}
+	return strings.Join(blocks, "\n\n")
+}
@@ -0,0 +1,190 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/jordan-simonovski/helmver/internal/clock"
+)
+
+// DefaultHeader is the top-level heading of a new CHANGELOG.md.
+const DefaultHeader = "# Changelog"
+
+// DefaultTemplate renders a release entry in helmver's standard format.
+const DefaultTemplate = "## {{.Version}} ({{.Date}})\n\n{{.Body}}\n"
+
+// Entry is the data available to a changelog template.
+type Entry struct {
+	Chart           string // chart name
+	Version         string // new chart version
+	PreviousVersion string
+	Bump            string
+	AppVersion      string // new appVersion; empty when unchanged
+	Date            string // release date; Render fills in today when empty
+	Changes         []Change
+	RepoURL         string // repository web URL from config, may be empty
+}
+
+// Body is the standard rendering of the entry's changes; see Body.
+func (e Entry) Body() string {
+	return strings.TrimRight(Body(e.Changes), "\n")
+}
+
+// Breaking reports whether the release is a major version bump.
+func (e Entry) Breaking() bool {
+	return e.Bump == "major" || e.Bump == "premajor"
+}
+
+// Sections groups the entry's changes by type in Keep a Changelog order,
+// the same way Body does: when no change has a type they form a single
+// section with an empty Type and Title, and otherwise untyped changes
+// (such as dependency updates) are in the "changed" section.
+func (e Entry) Sections() []Section {
+	typed := false
+	for _, c := range e.Changes {
+		if c.Type != "" {
+			typed = true
+			break
+		}
+	}
+	byType := make(map[string][]Change)
+	for _, c := range e.Changes {
+		if strings.TrimSpace(c.Message) == "" {
+			continue
+		}
+		typ := c.Type
+		if typ == "" && typed {
+			typ = "changed"
+		}
+		byType[typ] = append(byType[typ], c)
+	}
+	var out []Section
+	if len(byType[""]) > 0 {
+		out = append(out, Section{Changes: byType[""]})
+	}
+	for _, s := range sections {
+		if len(byType[s.typ]) > 0 {
+			out = append(out, Section{Type: s.typ, Title: s.title, Changes: byType[s.typ]})
+		}
+	}
+	return out
+}
+
+// Section is a group of changes of one type.
+type Section struct {
+	Type    string // e.g. "added"; empty when no change has a type
+	Title   string // e.g. "Added"
+	Changes []Change
+}
+
+// Renderer writes CHANGELOG.md entries from a text/template.
+type Renderer struct {
+	header string
+	tmpl   *template.Template
+}
+
+// Default renders entries with DefaultTemplate and DefaultHeader.
+var Default = MustRenderer("", "")
+
+// NewRenderer parses an entry template. An empty text or header selects
+// DefaultTemplate or DefaultHeader.
+func NewRenderer(text, header string) (*Renderer, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	if header == "" {
+		header = DefaultHeader
+	}
+	tmpl, err := template.New("changelog").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing changelog template: %w", err)
+	}
+	return &Renderer{header: strings.TrimRight(header, "\n"), tmpl: tmpl}, nil
+}
+
+// MustRenderer is NewRenderer that panics on error.
+func MustRenderer(text, header string) *Renderer {
+	r, err := NewRenderer(text, header)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Entry renders one release entry.
+func (r *Renderer) Entry(e Entry) (string, error) {
+	if e.Date == "" {
+		e.Date = clock.System.Date()
+	}
+	var b strings.Builder
+	if err := r.tmpl.Execute(&b, e); err != nil {
+		return "", fmt.Errorf("rendering changelog for %s %s: %w", e.Chart, e.Version, err)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// Render returns the CHANGELOG.md content with a new entry inserted after
+// the top-level heading. A nil existing means the file does not exist yet,
+// and it is created with the renderer's header.
+func (r *Renderer) Render(existing []byte, e Entry) ([]byte, error) {
+	entry, err := r.Entry(e)
+	if err != nil {
+		return nil, err
+	}
+	return insert(existing, r.header+"\n", entry), nil
+}
+
+// Prepend adds a new entry to the top of CHANGELOG.md in dir. It returns
+// ErrVersionExists, and leaves the file alone, when the changelog already
+// has an entry for e.Version.
+func (r *Renderer) Prepend(dir string, e Entry) error {
+	path := Path(dir)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if _, err := Parse(existing).Find(e.Version); err == nil {
+		return fmt.Errorf("%s: %w: %s", path, ErrVersionExists, e.Version)
+	}
+	out, err := r.Render(existing, e)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+var issueNumber = regexp.MustCompile(`^#?(\d+)$`)
+
+var funcs = template.FuncMap{
+	// prLink links a pull request number to RepoURL/pull/N. Other
+	// references, or an empty repoURL, are returned unchanged.
+	"prLink": func(repoURL, ref string) string { return refLink(repoURL, "pull", ref) },
+	// issueLink links an issue number to RepoURL/issues/N.
+	"issueLink": func(repoURL, ref string) string { return refLink(repoURL, "issues", ref) },
+	// indent indents every line after the first by n spaces, for
+	// multi-line messages inside list items.
+	"indent": func(n int, s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+strings.Repeat(" ", n))
+	},
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+}
+
+func refLink(repoURL, kind, ref string) string {
+	m := issueNumber.FindStringSubmatch(ref)
+	if repoURL == "" || m == nil {
+		return ref
+	}
+	return fmt.Sprintf("[#%s](%s/%s/%s)", m[1], strings.TrimRight(repoURL, "/"), kind, m[1])
+}
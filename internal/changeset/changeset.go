@@ -6,28 +6,70 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jordan-simonovski/helmver/internal/chart"
 )
 
 // Entry represents one chart's intended change within a changeset file.
 type Entry struct {
-	Chart string // chart name
-	Bump  string // "patch", "minor", or "major"
+	Chart      string // chart name, or chart path (ID) when names are ambiguous; see chart.Index.Resolve
+	Bump       string // one of chart.BumpKinds, e.g. "patch", "minor", "prerelease"
+	AppVersion string // optional: explicit appVersion or a bump type
+}
+
+// ChangeTypes lists the values accepted for a changeset's type field.
+var ChangeTypes = []string{"added", "changed", "deprecated", "removed", "fixed", "security"}
+
+// ValidType reports whether t is one of ChangeTypes.
+func ValidType(t string) bool {
+	for _, k := range ChangeTypes {
+		if k == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata holds the optional, non-chart fields of a changeset's front
+// matter.
+type Metadata struct {
+	Type    string   // one of ChangeTypes, or empty
+	Issues  []string // issue references, e.g. "#123" or a URL
+	PR      string   // pull request reference
+	Authors []string
 }
 
+// metadataKeys are the front matter keys read as Metadata rather than as
+// chart names, unless their value is a bump.
+var metadataKeys = map[string]bool{"type": true, "issues": true, "pr": true, "authors": true}
+
 // File represents a parsed .helmver changeset file.
 type File struct {
 	Path    string
 	Entries []Entry
 	Message string
+	Metadata
+}
+
+// Change is one changeset's contribution to a chart's release.
+type Change struct {
+	Message string
+	Metadata
 }
 
-// Resolved holds the aggregated bump and collected messages for one chart
+// Resolved holds the aggregated bump and collected changes for one chart
 // across all changeset files.
 type Resolved struct {
-	Chart    string
-	Bump     string
-	Messages []string
+	Chart      string
+	Bump       string
+	AppVersion string   // empty when no changeset sets appVersion
+	Changes    []Change // one per changeset file, in file order
+
+	appVersionFile string // the file that set an explicit AppVersion
 }
 
 // Dir returns the .helmver directory path under root.
@@ -38,6 +80,11 @@ func Dir(root string) string {
 // Write creates a new changeset file in .helmver/ with a random ID.
 // Returns the absolute path of the created file.
 func Write(root string, entries []Entry, message string) (string, error) {
+	return WriteFile(root, &File{Entries: entries, Message: message})
+}
+
+// WriteFile is Write for a changeset with metadata. f.Path is ignored.
+func WriteFile(root string, f *File) (string, error) {
 	dir := Dir(root)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return "", fmt.Errorf("creating .helmver directory: %w", err)
@@ -52,11 +99,23 @@ func Write(root string, entries []Entry, message string) (string, error) {
 
 	var b strings.Builder
 	b.WriteString("---\n")
-	for _, e := range entries {
+	for _, e := range f.Entries {
+		if e.AppVersion != "" {
+			fmt.Fprintf(&b, "%q: {bump: %s, appVersion: %q}\n", e.Chart, e.Bump, e.AppVersion)
+			continue
+		}
 		fmt.Fprintf(&b, "%q: %s\n", e.Chart, e.Bump)
 	}
+	if f.Type != "" {
+		fmt.Fprintf(&b, "type: %s\n", f.Type)
+	}
+	writeList(&b, "issues", f.Issues)
+	if f.PR != "" {
+		fmt.Fprintf(&b, "pr: %q\n", f.PR)
+	}
+	writeList(&b, "authors", f.Authors)
 	b.WriteString("---\n\n")
-	b.WriteString(strings.TrimRight(message, "\n"))
+	b.WriteString(strings.TrimRight(f.Message, "\n"))
 	b.WriteString("\n")
 
 	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
@@ -65,6 +124,17 @@ func Write(root string, entries []Entry, message string) (string, error) {
 	return path, nil
 }
 
+func writeList(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	fmt.Fprintf(b, "%s: [%s]\n", key, strings.Join(quoted, ", "))
+}
+
 // Discover finds and parses all changeset files in .helmver/.
 func Discover(root string) ([]*File, error) {
 	dir := Dir(root)
@@ -91,88 +161,230 @@ func Discover(root string) ([]*File, error) {
 	return files, nil
 }
 
-// Parse reads and validates a single changeset file.
+// Parse reads and validates a single changeset file. The front matter is
+// a YAML mapping of chart names to bumps, plus the optional metadata keys
+// type, issues, pr and authors. A chart value is either a bump type
+// ("minor") or a mapping with a bump and an appVersion
+// ({bump: minor, appVersion: "2.3.0"}).
 func Parse(path string) (*File, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	content := string(data)
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	content = strings.TrimPrefix(content, "\ufeff")
 	if !strings.HasPrefix(content, "---\n") {
 		return nil, fmt.Errorf("missing front matter opening in %s", path)
 	}
 
 	rest := content[4:]
-	idx := strings.Index(rest, "---\n")
-	if idx < 0 {
-		return nil, fmt.Errorf("missing front matter closing in %s", path)
+	var frontMatter, body string
+	switch {
+	case strings.HasPrefix(rest, "---\n") || rest == "---":
+		body = strings.TrimPrefix(rest, "---")
+	default:
+		idx := strings.Index(rest, "\n---\n")
+		if idx < 0 {
+			if !strings.HasSuffix(rest, "\n---") {
+				return nil, fmt.Errorf("missing front matter closing in %s", path)
+			}
+			idx = len(rest) - 4
+		}
+		frontMatter = rest[:idx+1]
+		body = rest[min(idx+5, len(rest)):]
+	}
+
+	f := &File{Path: path, Message: strings.TrimSpace(body)}
+	if err := f.parseFrontMatter(frontMatter); err != nil {
+		return nil, fmt.Errorf("%w in %s", err, path)
+	}
+	if len(f.Entries) == 0 {
+		return nil, fmt.Errorf("no entries in %s", path)
 	}
+	return f, nil
+}
 
-	frontMatter := rest[:idx]
-	message := strings.TrimSpace(rest[idx+4:])
+func (f *File) parseFrontMatter(src string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return fmt.Errorf("invalid front matter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return fmt.Errorf("front matter must be a mapping")
+	}
 
-	var entries []Entry
-	for _, line := range strings.Split(frontMatter, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, val := m.Content[i], m.Content[i+1]
+		name := key.Value
+		if seen[name] {
+			return fmt.Errorf("duplicate key %q", name)
+		}
+		seen[name] = true
+		if metadataKeys[name] && !isBumpValue(val) {
+			if err := f.setMetadata(name, val); err != nil {
+				return err
+			}
 			continue
 		}
-		colonIdx := strings.LastIndex(line, ":")
-		if colonIdx < 0 {
-			return nil, fmt.Errorf("invalid entry %q in %s", line, path)
+
+		e, err := parseEntryValue(name, val)
+		if err != nil {
+			return fmt.Errorf("invalid entry for chart %q: %w", name, err)
 		}
-		name := strings.TrimSpace(line[:colonIdx])
-		name = strings.Trim(name, "\"")
-		bump := strings.TrimSpace(line[colonIdx+1:])
+		if !chart.ValidBump(e.Bump) {
+			return fmt.Errorf("invalid bump type %q for chart %q", e.Bump, name)
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return nil
+}
 
-		if bump != "patch" && bump != "minor" && bump != "major" {
-			return nil, fmt.Errorf("invalid bump type %q for chart %q in %s", bump, name, path)
+// isBumpValue reports whether val reads as a chart entry, so that a chart
+// named like a metadata key (e.g. "type: minor") still works.
+func isBumpValue(val *yaml.Node) bool {
+	switch val.Kind {
+	case yaml.ScalarNode:
+		return chart.ValidBump(val.Value)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(val.Content); i += 2 {
+			if val.Content[i].Value == "bump" {
+				return true
+			}
 		}
-		entries = append(entries, Entry{Chart: name, Bump: bump})
 	}
+	return false
+}
 
-	if len(entries) == 0 {
-		return nil, fmt.Errorf("no entries in %s", path)
+func (f *File) setMetadata(key string, val *yaml.Node) error {
+	switch key {
+	case "type":
+		if val.Kind != yaml.ScalarNode || !ValidType(val.Value) {
+			return fmt.Errorf("invalid type %q (use one of: %s)", val.Value, strings.Join(ChangeTypes, ", "))
+		}
+		f.Type = val.Value
+	case "pr":
+		if val.Kind != yaml.ScalarNode {
+			return fmt.Errorf("pr must be a single value")
+		}
+		f.PR = val.Value
+	case "issues":
+		list, err := scalarList(key, val)
+		if err != nil {
+			return err
+		}
+		f.Issues = list
+	case "authors":
+		list, err := scalarList(key, val)
+		if err != nil {
+			return err
+		}
+		f.Authors = list
 	}
+	return nil
+}
 
-	return &File{
-		Path:    path,
-		Entries: entries,
-		Message: message,
-	}, nil
+// scalarList reads a scalar or a sequence of scalars.
+func scalarList(key string, val *yaml.Node) ([]string, error) {
+	switch val.Kind {
+	case yaml.ScalarNode:
+		if val.Value == "" {
+			return nil, nil
+		}
+		return []string{val.Value}, nil
+	case yaml.SequenceNode:
+		out := make([]string, 0, len(val.Content))
+		for _, item := range val.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s must be a list of values", key)
+			}
+			out = append(out, item.Value)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s must be a value or a list of values", key)
 }
 
-// ChartNames returns the set of chart names referenced across all files.
-func ChartNames(files []*File) map[string]bool {
-	names := make(map[string]bool)
-	for _, f := range files {
-		for _, e := range f.Entries {
-			names[e.Chart] = true
+// parseEntryValue parses either a bare bump type ("minor") or a mapping
+// with a bump and an appVersion ({bump: minor, appVersion: "2.3.0"}).
+func parseEntryValue(name string, val *yaml.Node) (Entry, error) {
+	switch val.Kind {
+	case yaml.ScalarNode:
+		return Entry{Chart: name, Bump: val.Value}, nil
+	case yaml.MappingNode:
+		var v struct {
+			Bump       string `yaml:"bump"`
+			AppVersion string `yaml:"appVersion"`
+		}
+		if err := val.Decode(&v); err != nil {
+			return Entry{}, err
 		}
+		return Entry{Chart: name, Bump: v.Bump, AppVersion: v.AppVersion}, nil
 	}
-	return names
+	return Entry{}, fmt.Errorf("expected a bump type or a mapping")
 }
 
-// Aggregate groups entries by chart name across all files.
-// For each chart, the highest bump wins and messages are collected in order.
-func Aggregate(files []*File) map[string]*Resolved {
+// Aggregate groups entries by their Chart reference across all files.
+// For each chart, the highest bump wins and changes are collected in order,
+// one per file.
+// Two files that set different explicit appVersions for one chart are a
+// conflict: the error names both files, and the returned map, which is
+// complete either way, keeps the first value.
+func Aggregate(files []*File) (map[string]*Resolved, error) {
 	m := make(map[string]*Resolved)
+	var conflict error
 	for _, f := range files {
+		// A file can name one chart twice once entries are resolved (by
+		// name and by path); its change is still added only once.
+		added := make(map[string]bool)
 		for _, e := range f.Entries {
 			r, ok := m[e.Chart]
 			if !ok {
 				r = &Resolved{Chart: e.Chart, Bump: e.Bump}
 				m[e.Chart] = r
-			} else if bumpRank(e.Bump) > bumpRank(r.Bump) {
+			} else if chart.BumpRank(e.Bump) > chart.BumpRank(r.Bump) {
 				r.Bump = e.Bump
 			}
-			if f.Message != "" {
-				r.Messages = append(r.Messages, f.Message)
+			if e.AppVersion != "" && !chart.ValidBump(e.AppVersion) {
+				if r.appVersionFile == "" {
+					r.appVersionFile = f.Path
+				} else if e.AppVersion != r.AppVersion && conflict == nil {
+					conflict = fmt.Errorf("conflicting appVersion for %s: %s sets %q, %s sets %q",
+						e.Chart, filepath.Base(r.appVersionFile), r.AppVersion, filepath.Base(f.Path), e.AppVersion)
+				}
+			}
+			r.AppVersion = mergeAppVersion(r.AppVersion, e.AppVersion)
+			if !added[e.Chart] {
+				added[e.Chart] = true
+				r.Changes = append(r.Changes, Change{Message: f.Message, Metadata: f.Metadata})
 			}
 		}
 	}
-	return m
+	return m, conflict
+}
+
+// mergeAppVersion combines two appVersion values. An explicit version beats
+// a bump type, and the first explicit version is kept (Aggregate reports a
+// different second one); between bump types the highest wins.
+func mergeAppVersion(current, next string) string {
+	switch {
+	case next == "":
+		return current
+	case current == "":
+		return next
+	case !chart.ValidBump(current):
+		return current
+	case !chart.ValidBump(next):
+		return next
+	case chart.BumpRank(next) > chart.BumpRank(current):
+		return next
+	}
+	return current
 }
 
 // Remove deletes a consumed changeset file.
@@ -180,19 +392,6 @@ func Remove(path string) error {
 	return os.Remove(path)
 }
 
-func bumpRank(bump string) int {
-	switch bump {
-	case "major":
-		return 3
-	case "minor":
-		return 2
-	case "patch":
-		return 1
-	default:
-		return 0
-	}
-}
-
 func randomID() (string, error) {
 	b := make([]byte, 8)
 	if _, err := rand.Read(b); err != nil {
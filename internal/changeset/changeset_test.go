@@ -3,6 +3,7 @@ package changeset
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -74,7 +75,10 @@ func TestAggregate_HighestBumpWins(t *testing.T) {
 		{Entries: []Entry{{Chart: "api", Bump: "patch"}}, Message: "fix2"},
 	}
 
-	resolved := Aggregate(files)
+	resolved, err := Aggregate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
 	r := resolved["api"]
 	if r == nil {
 		t.Fatal("expected resolved entry for api")
@@ -82,8 +86,24 @@ func TestAggregate_HighestBumpWins(t *testing.T) {
 	if r.Bump != "minor" {
 		t.Errorf("expected minor, got %q", r.Bump)
 	}
-	if len(r.Messages) != 3 {
-		t.Errorf("expected 3 messages, got %d", len(r.Messages))
+	if len(r.Changes) != 3 || r.Changes[0].Message != "fix1" || r.Changes[2].Message != "fix2" {
+		t.Errorf("expected the 3 changes in file order, got %+v", r.Changes)
+	}
+}
+
+func TestAggregate_SameChartTwiceInOneFile(t *testing.T) {
+	// apply resolves "api" and "charts/api" to the same chart ID.
+	files := []*File{
+		{Entries: []Entry{{Chart: "charts/api", Bump: "patch"}, {Chart: "charts/api", Bump: "minor"}}, Message: "feat"},
+	}
+
+	resolved, err := Aggregate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := resolved["charts/api"]
+	if r.Bump != "minor" || len(r.Changes) != 1 {
+		t.Errorf("expected one minor change, got %q with %+v", r.Bump, r.Changes)
 	}
 }
 
@@ -96,7 +116,10 @@ func TestAggregate_MultipleCharts(t *testing.T) {
 		{Entries: []Entry{{Chart: "web", Bump: "major"}}, Message: "breaking"},
 	}
 
-	resolved := Aggregate(files)
+	resolved, err := Aggregate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if resolved["api"].Bump != "minor" {
 		t.Errorf("api: expected minor, got %q", resolved["api"].Bump)
 	}
@@ -105,20 +128,6 @@ func TestAggregate_MultipleCharts(t *testing.T) {
 	}
 }
 
-func TestChartNames(t *testing.T) {
-	files := []*File{
-		{Entries: []Entry{{Chart: "a", Bump: "patch"}}},
-		{Entries: []Entry{{Chart: "b", Bump: "minor"}, {Chart: "a", Bump: "minor"}}},
-	}
-	names := ChartNames(files)
-	if !names["a"] || !names["b"] {
-		t.Errorf("expected a and b, got %v", names)
-	}
-	if names["c"] {
-		t.Error("unexpected chart c")
-	}
-}
-
 func TestParse_InvalidFrontMatter(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "bad.md")
@@ -153,3 +162,202 @@ func TestRemove(t *testing.T) {
 		t.Error("file should have been deleted")
 	}
 }
+
+func TestParse_PrereleaseBumpTypes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pre.md")
+	content := "---\n\"api\": prerelease\n\"web\": preminor\n\"worker\": release\n---\n\nrc\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(f.Entries) != 3 || f.Entries[0].Bump != "prerelease" || f.Entries[1].Bump != "preminor" || f.Entries[2].Bump != "release" {
+		t.Errorf("unexpected entries: %+v", f.Entries)
+	}
+}
+
+func TestWriteAndParse_AppVersion(t *testing.T) {
+	dir := t.TempDir()
+	entries := []Entry{
+		{Chart: "api", Bump: "minor", AppVersion: "2.3.0"},
+		{Chart: "worker", Bump: "patch", AppVersion: "patch"},
+		{Chart: "web", Bump: "patch"},
+	}
+
+	path, err := Write(dir, entries, "new release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for i, want := range entries {
+		if f.Entries[i] != want {
+			t.Errorf("entry %d: got %+v, want %+v", i, f.Entries[i], want)
+		}
+	}
+}
+
+func TestAggregate_AppVersion(t *testing.T) {
+	files := []*File{
+		{Entries: []Entry{{Chart: "api", Bump: "patch", AppVersion: "patch"}}},
+		{Entries: []Entry{{Chart: "api", Bump: "patch", AppVersion: "minor"}}},
+		{Entries: []Entry{{Chart: "web", Bump: "patch", AppVersion: "minor"}}},
+		{Entries: []Entry{{Chart: "web", Bump: "patch", AppVersion: "4.0.0"}}},
+		{Entries: []Entry{{Chart: "web", Bump: "patch", AppVersion: "major"}}},
+	}
+
+	resolved, err := Aggregate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved["api"].AppVersion; got != "minor" {
+		t.Errorf("api: highest appVersion bump should win, got %q", got)
+	}
+	if got := resolved["web"].AppVersion; got != "4.0.0" {
+		t.Errorf("web: explicit appVersion should win, got %q", got)
+	}
+}
+
+func TestAggregate_AppVersionConflict(t *testing.T) {
+	files := []*File{
+		{Path: "/repo/.helmver/a.md", Entries: []Entry{{Chart: "api", Bump: "patch", AppVersion: "2.0.0"}}},
+		{Path: "/repo/.helmver/b.md", Entries: []Entry{{Chart: "api", Bump: "patch", AppVersion: "2.0.0"}}},
+		{Path: "/repo/.helmver/c.md", Entries: []Entry{{Chart: "api", Bump: "minor", AppVersion: "major"}}},
+	}
+	if _, err := Aggregate(files); err != nil {
+		t.Fatalf("the same explicit appVersion twice is not a conflict: %v", err)
+	}
+
+	files = append(files, &File{Path: "/repo/.helmver/d.md", Entries: []Entry{{Chart: "api", Bump: "patch", AppVersion: "2.1.0"}}})
+	_, err := Aggregate(files)
+	if err == nil {
+		t.Fatal("expected a conflict error")
+	}
+	for _, want := range []string{"api", "a.md", `"2.0.0"`, "d.md", `"2.1.0"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func writeChangeset(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cs.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParse_Metadata(t *testing.T) {
+	path := writeChangeset(t, "---\n"+
+		"api: minor\n"+
+		"worker:\n  bump: patch\n  appVersion: \"1.2.0\"\n"+
+		"type: fixed\n"+
+		"issues: [\"#12\", 14]\n"+
+		"pr: 42\n"+
+		"authors:\n  - alice\n  - bob\n"+
+		"---\n\nFix probes\n")
+
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Entry{{Chart: "api", Bump: "minor"}, {Chart: "worker", Bump: "patch", AppVersion: "1.2.0"}}
+	if len(f.Entries) != 2 || f.Entries[0] != want[0] || f.Entries[1] != want[1] {
+		t.Errorf("entries: got %+v", f.Entries)
+	}
+	if f.Type != "fixed" || f.PR != "42" {
+		t.Errorf("type/pr: got %q/%q", f.Type, f.PR)
+	}
+	if len(f.Issues) != 2 || f.Issues[0] != "#12" || f.Issues[1] != "14" {
+		t.Errorf("issues: got %v", f.Issues)
+	}
+	if len(f.Authors) != 2 || f.Authors[1] != "bob" {
+		t.Errorf("authors: got %v", f.Authors)
+	}
+	if f.Message != "Fix probes" {
+		t.Errorf("message: got %q", f.Message)
+	}
+}
+
+func TestParse_CRLFAndColonNames(t *testing.T) {
+	path := writeChangeset(t, "---\r\n\"team:api\": minor\r\nweb:v2: patch\r\n---\r\n\r\nLine one\r\nLine two\r\n")
+
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(f.Entries) != 2 || f.Entries[0].Chart != "team:api" || f.Entries[1].Chart != "web:v2" {
+		t.Errorf("entries: got %+v", f.Entries)
+	}
+	if f.Message != "Line one\nLine two" {
+		t.Errorf("message: got %q", f.Message)
+	}
+}
+
+func TestParse_ChartNamedLikeMetadataKey(t *testing.T) {
+	f, err := Parse(writeChangeset(t, "---\ntype: minor\n---\n\nmsg\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(f.Entries) != 1 || f.Entries[0].Chart != "type" || f.Type != "" {
+		t.Errorf("expected a chart named type, got %+v", f)
+	}
+}
+
+func TestParse_InvalidMetadata(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown type":   "---\napi: patch\ntype: improved\n---\n\nmsg\n",
+		"nested issues":  "---\napi: patch\nissues: [[1]]\n---\n\nmsg\n",
+		"duplicate key":  "---\napi: patch\napi: minor\n---\n\nmsg\n",
+		"only metadata":  "---\ntype: fixed\n---\n\nmsg\n",
+		"not a mapping":  "---\n- api\n---\n\nmsg\n",
+		"unclosed":       "---\napi: patch\n\nmsg\n",
+		"unknown fields": "---\napi: patch\nowner: team-a\n---\n\nmsg\n",
+	} {
+		if _, err := Parse(writeChangeset(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriteFileAndParse_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	in := &File{
+		Entries:  []Entry{{Chart: "api", Bump: "minor"}},
+		Message:  "Add probes",
+		Metadata: Metadata{Type: "added", Issues: []string{"#7"}, PR: "9", Authors: []string{"alice"}},
+	}
+	path, err := WriteFile(dir, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Type != "added" || f.PR != "9" || len(f.Issues) != 1 || f.Issues[0] != "#7" || len(f.Authors) != 1 {
+		t.Errorf("metadata did not round trip: %+v", f.Metadata)
+	}
+}
+
+func TestAggregate_Changes(t *testing.T) {
+	files := []*File{
+		{Entries: []Entry{{Chart: "api", Bump: "patch"}}, Message: "fix", Metadata: Metadata{Type: "fixed"}},
+		{Entries: []Entry{{Chart: "api", Bump: "minor"}}, Message: "feat", Metadata: Metadata{Type: "added", PR: "3"}},
+	}
+	resolved, err := Aggregate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := resolved["api"]
+	if len(r.Changes) != 2 || r.Changes[0].Type != "fixed" || r.Changes[1].Message != "feat" || r.Changes[1].PR != "3" {
+		t.Errorf("changes: got %+v", r.Changes)
+	}
+}
@@ -0,0 +1,153 @@
+package chart
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Annotation returns the value of annotations[name] and whether it is set.
+func (c *Chart) Annotation(name string) (string, bool) {
+	_, ann := c.field("annotations")
+	if ann == nil || ann.Kind != yaml.MappingNode {
+		return "", false
+	}
+	for i := 0; i+1 < len(ann.Content); i += 2 {
+		if ann.Content[i].Value == name {
+			return ann.Content[i+1].Value, true
+		}
+	}
+	return "", false
+}
+
+// UpdateAnnotation sets annotations[name] in memory only; see Content. A
+// multi-line value is written as a literal block scalar (|), anything else
+// as a scalar that keeps the existing quoting style. Missing annotations
+// mappings and keys are added; nothing else in the file changes.
+func (c *Chart) UpdateAnnotation(name, value string) error {
+	annKey, ann := c.field("annotations")
+	if annKey == nil {
+		return c.appendAnnotations(name, value)
+	}
+
+	switch {
+	case ann.Kind == yaml.ScalarNode && ann.Tag == "!!null":
+		// "annotations:" with no entries yet.
+		return c.insertEntry(annKey, annKey.Column-1+2, name, value)
+	case ann.Kind != yaml.MappingNode || ann.Style == yaml.FlowStyle:
+		return fmt.Errorf("%s:%d: annotations must be a block mapping to be edited in place", c.Path, annKey.Line)
+	}
+
+	for i := 0; i+1 < len(ann.Content); i += 2 {
+		key, val := ann.Content[i], ann.Content[i+1]
+		if key.Value != name {
+			continue
+		}
+		if !strings.Contains(value, "\n") && !isBlock(val) {
+			return c.replaceScalars([]scalarEdit{{node: val, value: value}})
+		}
+		return c.replaceValue(key, val, renderBlock(value, key.Column-1+2, lineEnding(c.raw)))
+	}
+
+	return c.insertEntry(ann.Content[len(ann.Content)-2], ann.Content[0].Column-1, name, value)
+}
+
+// appendAnnotations adds an annotations mapping holding one entry at the
+// end of the file.
+func (c *Chart) appendAnnotations(name, value string) error {
+	eol := lineEnding(c.raw)
+	out := append([]byte(nil), c.raw...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, eol...)
+	}
+	out = append(out, "annotations:"+eol...)
+	out = append(out, renderEntry(name, value, 2, eol)...)
+	return c.parse(out)
+}
+
+// insertEntry adds "name: value" at the given indentation on the line
+// after the line holding after's value.
+func (c *Chart) insertEntry(after *yaml.Node, indent int, name, value string) error {
+	end, err := c.valueEndOf(after)
+	if err != nil {
+		return err
+	}
+	eol := lineEnding(c.raw)
+	insertAt := len(c.raw)
+	if i := strings.IndexByte(string(c.raw[end:]), '\n'); i >= 0 {
+		insertAt = end + i + 1
+	}
+
+	var b strings.Builder
+	if insertAt > 0 && c.raw[insertAt-1] != '\n' {
+		b.WriteString(eol)
+	}
+	b.WriteString(renderEntry(name, value, indent, eol))
+
+	out := append([]byte(nil), c.raw[:insertAt]...)
+	out = append(out, b.String()...)
+	out = append(out, c.raw[insertAt:]...)
+	return c.parse(out)
+}
+
+// replaceValue swaps the bytes of val, the value of key, for text.
+func (c *Chart) replaceValue(key, val *yaml.Node, text string) error {
+	start, err := c.offset(val.Line, val.Column)
+	if err != nil {
+		return err
+	}
+	end, err := c.valueEnd(key, val)
+	if err != nil {
+		return err
+	}
+	out := append([]byte(nil), c.raw[:start]...)
+	out = append(out, text...)
+	out = append(out, c.raw[end:]...)
+	return c.parse(out)
+}
+
+// valueEndOf returns the end offset of the value of key in its mapping;
+// for a key with an empty value it is the end of the key.
+func (c *Chart) valueEndOf(key *yaml.Node) (int, error) {
+	k, v := c.fieldFor(key)
+	if k == nil || v == nil || (v.Kind == yaml.ScalarNode && v.Tag == "!!null" && v.Value == "") {
+		start, err := c.offset(key.Line, key.Column)
+		if err != nil {
+			return 0, err
+		}
+		return scalarEnd(c.raw, start, key, c.inFlow(key))
+	}
+	return c.valueEnd(k, v)
+}
+
+// valueEnd returns the byte offset just past the scalar value of key.
+func (c *Chart) valueEnd(key, val *yaml.Node) (int, error) {
+	if val.Kind != yaml.ScalarNode {
+		return 0, fmt.Errorf("%s:%d: expected a scalar value", c.Path, val.Line)
+	}
+	start, err := c.offset(val.Line, val.Column)
+	if err != nil {
+		return 0, err
+	}
+	if isBlock(val) {
+		return blockEnd(c.raw, start, key.Column-1), nil
+	}
+	end, err := scalarEnd(c.raw, start, val, c.inFlow(val))
+	if err != nil {
+		return 0, fmt.Errorf("%s:%d: %w", c.Path, val.Line, err)
+	}
+	return end, nil
+}
+
+func renderEntry(name, value string, indent int, eol string) string {
+	key := name
+	if !plainSafe(name) {
+		key = doubleQuote(name)
+	}
+	pad := strings.Repeat(" ", indent)
+	if strings.Contains(value, "\n") {
+		return pad + key + ": " + renderBlock(value, indent+2, eol) + eol
+	}
+	return pad + key + ": " + renderScalar(value, yaml.DoubleQuotedStyle) + eol
+}
@@ -0,0 +1,111 @@
+package chart
+
+import "testing"
+
+const changesV1 = "- kind: added\n  description: First\n"
+const changesV2 = "- kind: fixed\n  description: Second\n  links:\n    - name: \"#4\"\n      url: https://example.com/4\n"
+
+func TestUpdateAnnotation_replaceBlock(t *testing.T) {
+	c := loadRaw(t, `apiVersion: v2
+name: app
+version: 1.0.0
+annotations:
+  # release notes
+  artifacthub.io/changes: |
+    - kind: added
+      description: First
+
+  artifacthub.io/license: MIT
+# trailing comment
+`)
+	if err := c.UpdateAnnotation("artifacthub.io/changes", changesV2); err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v2
+name: app
+version: 1.0.0
+annotations:
+  # release notes
+  artifacthub.io/changes: |
+    - kind: fixed
+      description: Second
+      links:
+        - name: "#4"
+          url: https://example.com/4
+
+  artifacthub.io/license: MIT
+# trailing comment
+`
+	if got := string(c.Content()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if v, ok := c.Annotation("artifacthub.io/changes"); !ok || v != changesV2 {
+		t.Errorf("Annotation: got %q, %v", v, ok)
+	}
+}
+
+func TestUpdateAnnotation_replaceLastBlockAtEOF(t *testing.T) {
+	c := loadRaw(t, "version: 1.0.0\r\nannotations:\r\n  artifacthub.io/changes: |-\r\n    - kind: added\r\n      description: First")
+	if err := c.UpdateAnnotation("artifacthub.io/changes", changesV1); err != nil {
+		t.Fatal(err)
+	}
+	want := "version: 1.0.0\r\nannotations:\r\n  artifacthub.io/changes: |\r\n    - kind: added\r\n      description: First"
+	if got := string(c.Content()); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestUpdateAnnotation_scalar(t *testing.T) {
+	c := loadRaw(t, "version: 1.0.0\nannotations:\n  artifacthub.io/containsSecurityUpdates: 'false' # keep\n")
+	if err := c.UpdateAnnotation("artifacthub.io/containsSecurityUpdates", "true"); err != nil {
+		t.Fatal(err)
+	}
+	want := "version: 1.0.0\nannotations:\n  artifacthub.io/containsSecurityUpdates: 'true' # keep\n"
+	if got := string(c.Content()); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestUpdateAnnotation_insertKey(t *testing.T) {
+	c := loadRaw(t, "version: 1.0.0\nannotations:\n    category: database\n    notes: |\n      line\nkeywords: [a]\n")
+	if err := c.UpdateAnnotation("artifacthub.io/changes", changesV1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateAnnotation("artifacthub.io/containsSecurityUpdates", "true"); err != nil {
+		t.Fatal(err)
+	}
+	want := "version: 1.0.0\nannotations:\n    category: database\n    notes: |\n      line\n" +
+		"    artifacthub.io/changes: |\n      - kind: added\n        description: First\n" +
+		"    artifacthub.io/containsSecurityUpdates: \"true\"\n" +
+		"keywords: [a]\n"
+	if got := string(c.Content()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUpdateAnnotation_emptyAndMissingMapping(t *testing.T) {
+	c := loadRaw(t, "version: 1.0.0\nannotations:\nkeywords: [a]\n")
+	if err := c.UpdateAnnotation("artifacthub.io/containsSecurityUpdates", "true"); err != nil {
+		t.Fatal(err)
+	}
+	want := "version: 1.0.0\nannotations:\n  artifacthub.io/containsSecurityUpdates: \"true\"\nkeywords: [a]\n"
+	if got := string(c.Content()); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	c = loadRaw(t, "version: 1.0.0")
+	if err := c.UpdateAnnotation("artifacthub.io/changes", changesV1); err != nil {
+		t.Fatal(err)
+	}
+	want = "version: 1.0.0\nannotations:\n  artifacthub.io/changes: |\n    - kind: added\n      description: First\n"
+	if got := string(c.Content()); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestUpdateAnnotation_flowMapping(t *testing.T) {
+	c := loadRaw(t, "version: 1.0.0\nannotations: {category: db}\n")
+	if err := c.UpdateAnnotation("artifacthub.io/changes", changesV1); err == nil {
+		t.Error("expected an error for a flow mapping")
+	}
+}
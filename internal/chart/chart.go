@@ -4,49 +4,68 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Chart holds the parsed metadata we care about plus the raw YAML tree.
+// Chart holds the parsed metadata we care about plus the raw file bytes.
+// Edits replace only the bytes of the changed scalar, so everything else in
+// Chart.yaml (comments, quoting, blank lines, line endings) is preserved.
 type Chart struct {
-	Name    string
-	Version string
-	Path    string // absolute path to Chart.yaml
-	Dir     string // directory containing Chart.yaml
-	Stale   bool   // true if chart has changes since last version bump
-	doc     yaml.Node
+	Name         string
+	Version      string
+	AppVersion   string
+	Dependencies []Dependency
+	Path         string // absolute path to Chart.yaml
+	Dir          string // directory containing Chart.yaml
+	ID           string // Dir relative to the Index root, set by NewIndex
+	Stale        bool   // true if chart has changes since last version bump
+	raw          []byte
+	doc          yaml.Node
 }
 
-// Load reads and parses a Chart.yaml, preserving the full YAML tree.
+// Load reads and parses a Chart.yaml, preserving the raw bytes and YAML tree.
 func Load(path string) (*Chart, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading %s: %w", path, err)
 	}
 
-	var doc yaml.Node
-	if err := yaml.Unmarshal(data, &doc); err != nil {
-		return nil, fmt.Errorf("parsing %s: %w", path, err)
-	}
-
 	c := &Chart{
 		Path: path,
 		Dir:  filepath.Dir(path),
-		doc:  doc,
+	}
+	if err := c.parse(data); err != nil {
+		return nil, err
+	}
+
+	if c.Version == "" {
+		return nil, fmt.Errorf("%s: missing version field", path)
+	}
+
+	return c, nil
+}
+
+// parse replaces the chart's raw bytes and refreshes the YAML tree and the
+// metadata fields derived from it.
+func (c *Chart) parse(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", c.Path, err)
 	}
 
 	// doc.Content[0] is the mapping node
 	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
-		return nil, fmt.Errorf("%s: unexpected YAML structure", path)
+		return fmt.Errorf("%s: unexpected YAML structure", c.Path)
 	}
 	mapping := doc.Content[0]
 	if mapping.Kind != yaml.MappingNode {
-		return nil, fmt.Errorf("%s: expected mapping at top level", path)
+		return fmt.Errorf("%s: expected mapping at top level", c.Path)
 	}
 
+	c.raw = data
+	c.doc = doc
+	c.Name, c.Version, c.AppVersion, c.Dependencies = "", "", "", nil
 	for i := 0; i < len(mapping.Content)-1; i += 2 {
 		key := mapping.Content[i]
 		val := mapping.Content[i+1]
@@ -55,85 +74,106 @@ func Load(path string) (*Chart, error) {
 			c.Name = val.Value
 		case "version":
 			c.Version = val.Value
+		case "appVersion":
+			c.AppVersion = val.Value
+		case "dependencies":
+			c.Dependencies = parseDependencies(val)
 		}
 	}
-
-	if c.Version == "" {
-		return nil, fmt.Errorf("%s: missing version field", path)
-	}
-
-	return c, nil
+	return nil
 }
 
-// SetVersion updates the version field in the YAML tree and writes it back to disk.
+// SetVersion updates the version field and writes the chart back to disk.
 func (c *Chart) SetVersion(newVersion string) error {
-	mapping := c.doc.Content[0]
-	for i := 0; i < len(mapping.Content)-1; i += 2 {
-		key := mapping.Content[i]
-		val := mapping.Content[i+1]
-		if key.Value == "version" {
-			val.Value = newVersion
-			c.Version = newVersion
-			break
-		}
+	if err := c.UpdateVersion(newVersion); err != nil {
+		return err
 	}
 	return c.write()
 }
 
-func (c *Chart) write() error {
-	f, err := os.Create(c.Path)
-	if err != nil {
-		return err
+// UpdateVersion updates the version field in memory only; see Content.
+func (c *Chart) UpdateVersion(newVersion string) error {
+	_, val := c.field("version")
+	if val == nil {
+		return fmt.Errorf("%s: missing version field", c.Path)
 	}
-	defer f.Close()
+	return c.replaceScalars([]scalarEdit{{node: val, value: newVersion}})
+}
 
-	enc := yaml.NewEncoder(f)
-	enc.SetIndent(2)
-	if err := enc.Encode(&c.doc); err != nil {
+// SetAppVersion updates the appVersion field and writes the chart back to
+// disk. If the chart has no appVersion yet, the field is added on the line
+// after version as a quoted string.
+func (c *Chart) SetAppVersion(newAppVersion string) error {
+	if err := c.UpdateAppVersion(newAppVersion); err != nil {
 		return err
 	}
-	return enc.Close()
+	return c.write()
 }
 
-// BumpVersion computes the next semver given a bump type.
-func BumpVersion(current, bump string) (string, error) {
-	// Strip leading 'v' if present
-	trimmed := strings.TrimPrefix(current, "v")
-	parts := strings.SplitN(trimmed, ".", 3)
-	if len(parts) != 3 {
-		return "", fmt.Errorf("version %q is not valid semver (expected X.Y.Z)", current)
+// UpdateAppVersion is SetAppVersion without writing to disk; see Content.
+func (c *Chart) UpdateAppVersion(newAppVersion string) error {
+	if _, val := c.field("appVersion"); val != nil {
+		return c.replaceScalars([]scalarEdit{{node: val, value: newAppVersion}})
 	}
 
-	major, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return "", fmt.Errorf("invalid major version %q: %w", parts[0], err)
-	}
-	minor, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return "", fmt.Errorf("invalid minor version %q: %w", parts[1], err)
+	key, _ := c.field("version")
+	if key == nil {
+		return fmt.Errorf("%s: missing version field", c.Path)
 	}
-	patch, err := strconv.Atoi(parts[2])
-	if err != nil {
-		return "", fmt.Errorf("invalid patch version %q: %w", parts[2], err)
+	return c.insertAfter(key, "appVersion", newAppVersion)
+}
+
+// Content returns the current bytes of Chart.yaml, including any edits
+// made with the Update methods that have not been written yet.
+func (c *Chart) Content() []byte {
+	return c.raw
+}
+
+// field returns the key and value nodes of a top-level Chart.yaml field.
+func (c *Chart) field(name string) (key, val *yaml.Node) {
+	mapping := c.doc.Content[0]
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
 	}
+	return nil, nil
+}
+
+func (c *Chart) write() error {
+	return os.WriteFile(c.Path, c.raw, 0o644)
+}
+
+// BumpVersion computes the next semver given a bump type, using
+// DefaultPreID for pre-release bumps.
+func BumpVersion(current, bump string) (string, error) {
+	return BumpVersionPre(current, bump, DefaultPreID)
+}
 
-	switch bump {
-	case "major":
-		major++
-		minor = 0
-		patch = 0
-	case "minor":
-		minor++
-		patch = 0
-	case "patch":
-		patch++
-	default:
-		return "", fmt.Errorf("unknown bump type %q", bump)
+// ResolveAppVersion computes a new appVersion from a changeset value.
+// A bump type (e.g. "minor") bumps the current appVersion as semver; any
+// other value is used verbatim.
+func ResolveAppVersion(current, value, preid string) (string, error) {
+	if !ValidBump(value) {
+		return value, nil
 	}
+	if current == "" {
+		return "", fmt.Errorf("cannot apply %s bump: chart has no appVersion", value)
+	}
+	return BumpVersionPre(current, value, preid)
+}
 
-	prefix := ""
-	if strings.HasPrefix(current, "v") {
-		prefix = "v"
+// BumpVersionPre computes the next semver given a bump type and the
+// pre-release identifier to use for premajor, preminor, prepatch and
+// prerelease bumps.
+func BumpVersionPre(current, bump, preid string) (string, error) {
+	v, err := ParseVersion(current)
+	if err != nil {
+		return "", err
+	}
+	next, err := v.Bump(bump, preid)
+	if err != nil {
+		return "", err
 	}
-	return fmt.Sprintf("%s%d.%d.%d", prefix, major, minor, patch), nil
+	return next.String(), nil
 }
@@ -22,8 +22,24 @@ func TestBumpVersion(t *testing.T) {
 		// v-prefix preserved
 		{"v1.0.0", "patch", "v1.0.1", false},
 		{"v0.0.0", "major", "v1.0.0", false},
+		// pre-release and build metadata
+		{"1.4.0-rc.1", "prerelease", "1.4.0-rc.2", false},
+		{"1.4.0-rc.1", "release", "1.4.0", false},
+		{"1.4.0-rc.1", "patch", "1.4.0", false},
+		{"1.4.0-rc.1", "minor", "1.4.0", false},
+		{"1.4.1-rc.1", "minor", "1.5.0", false},
+		{"2.0.0-rc.1", "major", "2.0.0", false},
+		{"1.4.0", "preminor", "1.5.0-rc.0", false},
+		{"1.4.0", "premajor", "2.0.0-rc.0", false},
+		{"1.4.0", "prepatch", "1.4.1-rc.0", false},
+		{"1.4.0", "prerelease", "1.4.1-rc.0", false},
+		{"2.0.0+build.5", "patch", "2.0.1", false},
+		{"v1.0.0-beta", "prerelease", "v1.0.0-beta.0", false},
 		// errors
 		{"1.2", "patch", "", true},
+		{"1.4.0", "release", "", true},
+		{"1.4.0-", "patch", "", true},
+		{"01.4.0", "patch", "", true},
 		{"abc", "patch", "", true},
 		{"1.2.3", "bogus", "", true},
 		{"1.x.3", "patch", "", true},
@@ -177,3 +193,77 @@ func containsBytes(s, sub string) bool {
 	}
 	return false
 }
+
+func TestSetAppVersion(t *testing.T) {
+	dir := t.TempDir()
+	chartPath := filepath.Join(dir, "Chart.yaml")
+
+	content := "apiVersion: v2\nname: app\nversion: 1.0.0\nappVersion: \"1.0\"\n"
+	if err := os.WriteFile(chartPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AppVersion != "1.0" {
+		t.Fatalf("AppVersion = %q, want 1.0", c.AppVersion)
+	}
+	if err := c.SetAppVersion("2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(chartPath)
+	if !contains(string(data), `appVersion: "2.0"`) {
+		t.Errorf("appVersion not updated with quoting preserved:\n%s", data)
+	}
+}
+
+func TestSetAppVersionAddsMissingField(t *testing.T) {
+	dir := t.TempDir()
+	chartPath := filepath.Join(dir, "Chart.yaml")
+
+	content := "apiVersion: v2\nname: app\nversion: 1.0.0\ntype: application\n"
+	if err := os.WriteFile(chartPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetAppVersion("3.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(chartPath)
+	if !contains(string(data), "version: 1.0.0\nappVersion: \"3.1.0\"\ntype: application") {
+		t.Errorf("appVersion should be inserted after version:\n%s", data)
+	}
+}
+
+func TestResolveAppVersion(t *testing.T) {
+	tests := []struct {
+		current, value, want string
+		wantErr              bool
+	}{
+		{"1.0.0", "2.5.0", "2.5.0", false},
+		{"1.0.0", "minor", "1.1.0", false},
+		{"", "latest", "latest", false},
+		{"", "patch", "", true},
+		{"not-semver", "patch", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveAppVersion(tt.current, tt.value, DefaultPreID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ResolveAppVersion(%q, %q): expected error", tt.current, tt.value)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveAppVersion(%q, %q) = %q, %v; want %q", tt.current, tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package chart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Satisfies reports whether v meets a Helm dependency version constraint:
+// comparisons (=, !=, >, <, >=, <=), tilde and caret ranges, wildcards
+// (1.2.x, 1.*, *), hyphen ranges (1.2 - 1.4), terms joined by spaces or
+// commas (and), and alternatives joined by || (or). Pre-releases are
+// compared by SemVer precedence like any other version.
+func Satisfies(constraint string, v Version) (bool, error) {
+	for _, alt := range strings.Split(constraint, "||") {
+		ok, err := satisfiesAll(alt, v)
+		if err != nil {
+			return false, fmt.Errorf("constraint %q: %w", constraint, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func satisfiesAll(alt string, v Version) (bool, error) {
+	fields := strings.Fields(strings.ReplaceAll(alt, ",", " "))
+	for i := 0; i < len(fields); i++ {
+		if i+2 < len(fields) && fields[i+1] == "-" {
+			// A hyphen range includes both ends; a partial upper end
+			// includes every version it matches.
+			lo, err := parsePartial(fields[i])
+			if err != nil {
+				return false, err
+			}
+			hi, err := parsePartial(fields[i+2])
+			if err != nil {
+				return false, err
+			}
+			i += 2
+			if v.Compare(lo.floor()) < 0 || !hi.atMost(v) {
+				return false, nil
+			}
+			continue
+		}
+		ok, err := satisfiesTerm(fields[i], v)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func satisfiesTerm(term string, v Version) (bool, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", "~>", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, o) {
+			op, term = o, term[len(o):]
+			break
+		}
+	}
+	p, err := parsePartial(term)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "", "=":
+		return p.matches(v), nil
+	case "!=":
+		return !p.matches(v), nil
+	case ">":
+		return !p.atMost(v), nil
+	case ">=":
+		return v.Compare(p.floor()) >= 0, nil
+	case "<":
+		return v.Compare(p.floor()) < 0, nil
+	case "<=":
+		return p.atMost(v), nil
+	case "~", "~>":
+		// ~1.2.3 and ~1.2 allow patch releases, ~1 minor releases.
+		return v.Compare(p.floor()) >= 0 && below(v, p.ceil(min(p.n, 2))), nil
+	case "^":
+		// ^1.2.3 allows releases that keep the first non-zero component:
+		// ^0.2.3 allows patch releases only.
+		k := 1
+		for k < p.n && p.parts[k-1] == 0 {
+			k++
+		}
+		return v.Compare(p.floor()) >= 0 && below(v, p.ceil(min(k, p.n))), nil
+	}
+	return false, fmt.Errorf("unknown operator in %q", term)
+}
+
+// partial is a version whose trailing components may be wildcards: 1.2,
+// 1.2.x, 1.* and * have two, one and no numeric components.
+type partial struct {
+	parts [3]int
+	n     int      // number of numeric components
+	pre   []string // pre-release, only with all three components
+}
+
+func parsePartial(s string) (partial, error) {
+	var p partial
+	s = strings.TrimPrefix(s, "v")
+	if v, err := ParseVersion(s); err == nil {
+		p.parts, p.n, p.pre = [3]int{v.Major, v.Minor, v.Patch}, 3, v.Prerelease
+		return p, nil
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return p, fmt.Errorf("invalid version %q", s)
+	}
+	wildcard := false
+	for _, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			wildcard = true
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || wildcard {
+			return p, fmt.Errorf("invalid version %q", s)
+		}
+		p.parts[p.n] = n
+		p.n++
+	}
+	return p, nil
+}
+
+// floor is the lowest version p matches.
+func (p partial) floor() Version {
+	return Version{Major: p.parts[0], Minor: p.parts[1], Patch: p.parts[2], Prerelease: p.pre}
+}
+
+// ceil is the lowest major, minor and patch above the versions matching
+// the first k components of p, or nil when k is 0 and nothing is above.
+func (p partial) ceil(k int) *[3]int {
+	if k == 0 {
+		return nil
+	}
+	var c [3]int
+	copy(c[:k], p.parts[:k])
+	c[k-1]++
+	return &c
+}
+
+// matches reports whether v is p, or has p's numeric components.
+func (p partial) matches(v Version) bool {
+	if p.n == 3 {
+		return v.Compare(p.floor()) == 0
+	}
+	return v.Compare(p.floor()) >= 0 && below(v, p.ceil(p.n))
+}
+
+// atMost reports whether v is at most p, counting every version p matches.
+func (p partial) atMost(v Version) bool {
+	if p.n == 3 {
+		return v.Compare(p.floor()) <= 0
+	}
+	return below(v, p.ceil(p.n))
+}
+
+// below reports whether v's major, minor and patch are lower than c, which
+// also keeps pre-releases of c out. Every version is below a nil c.
+func below(v Version, c *[3]int) bool {
+	if c == nil {
+		return true
+	}
+	got := [3]int{v.Major, v.Minor, v.Patch}
+	for i := range got {
+		if got[i] != c[i] {
+			return got[i] < c[i]
+		}
+	}
+	return false
+}
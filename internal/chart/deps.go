@@ -0,0 +1,177 @@
+package chart
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fileRepoPrefix = "file://"
+
+// Dependency is one entry of the Chart.yaml dependencies list.
+type Dependency struct {
+	Name       string
+	Version    string
+	Repository string
+
+	node *yaml.Node // the dependency's mapping in Chart.yaml
+}
+
+// LocalDir returns the absolute directory a file:// dependency points at,
+// resolved relative to chartDir. ok is false for remote repositories.
+func (d Dependency) LocalDir(chartDir string) (dir string, ok bool) {
+	if !strings.HasPrefix(d.Repository, fileRepoPrefix) {
+		return "", false
+	}
+	p := strings.TrimPrefix(d.Repository, fileRepoPrefix)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(chartDir, p)
+	}
+	return filepath.Clean(p), true
+}
+
+func parseDependencies(seq *yaml.Node) []Dependency {
+	if seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var deps []Dependency
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		d := Dependency{node: item}
+		for i := 0; i < len(item.Content)-1; i += 2 {
+			switch item.Content[i].Value {
+			case "name":
+				d.Name = item.Content[i+1].Value
+			case "version":
+				d.Version = item.Content[i+1].Value
+			case "repository":
+				d.Repository = item.Content[i+1].Value
+			}
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// SetDependencyVersion updates the version pin of every file:// dependency
+// that points at depDir and writes the chart back to disk; see pinVersion
+// for how each pin changes. Dependencies without a pin are left alone.
+// It returns an error if the chart has no dependency on depDir, or if a
+// pin excludes version and cannot be rewritten.
+func (c *Chart) SetDependencyVersion(depDir, version string) error {
+	if err := c.UpdateDependencyVersion(depDir, version); err != nil {
+		return err
+	}
+	return c.write()
+}
+
+// UpdateDependencyVersion is SetDependencyVersion without writing to disk;
+// see Content.
+func (c *Chart) UpdateDependencyVersion(depDir, version string) error {
+	found := false
+	var edits []scalarEdit
+	for _, d := range c.Dependencies {
+		dir, ok := d.LocalDir(c.Dir)
+		if !ok || dir != filepath.Clean(depDir) {
+			continue
+		}
+		found = true
+		item := d.node
+		for j := 0; j < len(item.Content)-1; j += 2 {
+			if item.Content[j].Value != "version" {
+				continue
+			}
+			pin, err := pinVersion(d.Version, version)
+			if err != nil {
+				return fmt.Errorf("%s: dependency %s: %w", c.Path, d.Name, err)
+			}
+			if pin != d.Version {
+				edits = append(edits, scalarEdit{node: item.Content[j+1], value: pin})
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s: no file:// dependency on %s", c.Path, depDir)
+	}
+	return c.replaceScalars(edits)
+}
+
+// pinVersion returns the new constraint for a dependency on a chart now
+// at version. An exact pin (1.2.0, =1.2.0) is replaced, and a ^ or ~ range
+// moves up to start at the new version. Any other constraint is kept when
+// it already allows the new version (>=1.2.0 <2.0.0, *); otherwise a
+// wildcard such as 1.2.x keeps its shape (1.3.x). A constraint that
+// excludes the new version and has no single version to rewrite is an
+// error.
+func pinVersion(old, version string) (string, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	pin := strings.TrimSpace(old)
+	if !strings.ContainsAny(pin, " ,|") {
+		for _, op := range []string{"^", "~>", "~", "="} {
+			if strings.HasPrefix(pin, op) {
+				if op == "=" {
+					if _, err := ParseVersion(pin[1:]); err != nil {
+						break
+					}
+				}
+				return op + version, nil
+			}
+		}
+		if p, err := ParseVersion(pin); err == nil {
+			return p.Prefix + strings.TrimPrefix(version, p.Prefix), nil
+		}
+	}
+
+	ok, err := Satisfies(pin, v)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return old, nil
+	}
+	if p, err := parsePartial(pin); err == nil && p.n > 0 && !strings.ContainsAny(pin, " ,|<>=!^~") {
+		// 1.2.x -> 1.3.x: replace the numeric components, keep the rest.
+		prefix := ""
+		if strings.HasPrefix(pin, "v") {
+			prefix, pin = "v", pin[1:]
+		}
+		fields := strings.Split(pin, ".")
+		parts := [3]int{v.Major, v.Minor, v.Patch}
+		for i := 0; i < p.n; i++ {
+			fields[i] = strconv.Itoa(parts[i])
+		}
+		return prefix + strings.Join(fields, "."), nil
+	}
+	return "", fmt.Errorf("constraint %q does not allow %s; update it by hand", old, version)
+}
+
+// Dependents maps each chart directory to the charts that depend on it via
+// a file:// repository in their Chart.yaml dependencies.
+func Dependents(charts []*Chart) map[string][]*Chart {
+	byDir := make(map[string]bool, len(charts))
+	for _, c := range charts {
+		byDir[c.Dir] = true
+	}
+
+	m := make(map[string][]*Chart)
+	for _, c := range charts {
+		seen := make(map[string]bool)
+		for _, d := range c.Dependencies {
+			dir, ok := d.LocalDir(c.Dir)
+			if !ok || !byDir[dir] || seen[dir] || dir == c.Dir {
+				continue
+			}
+			seen[dir] = true
+			m[dir] = append(m[dir], c)
+		}
+	}
+	return m
+}
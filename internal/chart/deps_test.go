@@ -0,0 +1,174 @@
+package chart
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeChart(t *testing.T, path, content string) *Chart {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDependents(t *testing.T) {
+	dir := t.TempDir()
+	common := writeChart(t, filepath.Join(dir, "common", "Chart.yaml"),
+		"apiVersion: v2\nname: common\nversion: 1.2.0\n")
+	api := writeChart(t, filepath.Join(dir, "api", "Chart.yaml"),
+		"apiVersion: v2\nname: api\nversion: 0.1.0\ndependencies:\n  - name: common\n    version: 1.2.0\n    repository: file://../common\n  - name: redis\n    version: 17.0.0\n    repository: https://charts.bitnami.com/bitnami\n")
+	web := writeChart(t, filepath.Join(dir, "web", "Chart.yaml"),
+		"apiVersion: v2\nname: web\nversion: 0.1.0\ndependencies:\n  - name: common\n    version: ~1.2.0\n    repository: \"file://../common\"\n")
+
+	m := Dependents([]*Chart{common, api, web})
+	got := m[common.Dir]
+	if len(got) != 2 || got[0] != api || got[1] != web {
+		t.Fatalf("expected api and web to depend on common, got %v", got)
+	}
+	if len(m[api.Dir]) != 0 {
+		t.Errorf("nothing depends on api, got %v", m[api.Dir])
+	}
+
+	if _, ok := api.Dependencies[1].LocalDir(api.Dir); ok {
+		t.Error("remote repository should not resolve to a local dir")
+	}
+}
+
+func TestSetDependencyVersion(t *testing.T) {
+	dir := t.TempDir()
+	common := writeChart(t, filepath.Join(dir, "common", "Chart.yaml"),
+		"apiVersion: v2\nname: common\nversion: 1.2.0\n")
+	web := writeChart(t, filepath.Join(dir, "web", "Chart.yaml"),
+		"apiVersion: v2\nname: web\nversion: 0.1.0\ndependencies:\n  - name: common\n    version: ~1.2.0\n    repository: file://../common\n")
+
+	if err := web.SetDependencyVersion(common.Dir, "1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(web.Path)
+	if !contains(string(data), "version: ~1.3.0") {
+		t.Errorf("dependency pin not updated:\n%s", data)
+	}
+	if web.Dependencies[0].Version != "~1.3.0" {
+		t.Errorf("Dependencies not updated: %+v", web.Dependencies)
+	}
+
+	if err := web.SetDependencyVersion(filepath.Join(dir, "other"), "1.0.0"); err == nil {
+		t.Error("expected error for a chart that is not a dependency")
+	}
+}
+
+func TestSetDependencyVersionAfterNonMappingItem(t *testing.T) {
+	dir := t.TempDir()
+	common := writeChart(t, filepath.Join(dir, "common", "Chart.yaml"),
+		"apiVersion: v2\nname: common\nversion: 1.2.0\n")
+	// The scalar item is not a dependency, so the file:// one is the first
+	// parsed dependency but the third item in the sequence.
+	web := writeChart(t, filepath.Join(dir, "web", "Chart.yaml"),
+		"apiVersion: v2\nname: web\nversion: 0.1.0\ndependencies:\n  - junk\n  - name: redis\n    version: 17.0.0\n    repository: https://charts.bitnami.com/bitnami\n  - name: common\n    version: 1.2.0\n    repository: file://../common\n")
+
+	if err := web.SetDependencyVersion(common.Dir, "1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(web.Path)
+	if !contains(string(data), "version: 17.0.0") || !contains(string(data), "version: 1.3.0\n    repository: file://../common") {
+		t.Errorf("wrong dependency pinned:\n%s", data)
+	}
+}
+
+func TestPinVersion(t *testing.T) {
+	tests := []struct {
+		old, version, want string
+	}{
+		{"1.2.0", "1.3.0", "1.3.0"},
+		{"v1.2.0", "1.3.0", "v1.3.0"},
+		{"=1.2.0", "1.3.0", "=1.3.0"},
+		{"^1.2.0", "1.3.0", "^1.3.0"},
+		{"~1.2.0", "1.3.0", "~1.3.0"},
+		{"~>1.2", "1.3.0", "~>1.3.0"},
+		// Constraints that allow the new version are kept.
+		{">=1.2.0 <2.0.0", "1.3.0", ">=1.2.0 <2.0.0"},
+		{">=1.2.0, <2.0.0", "1.3.0", ">=1.2.0, <2.0.0"},
+		{"1.x", "1.3.0", "1.x"},
+		{"*", "1.3.0", "*"},
+		{"1.2 - 1.4", "1.3.0", "1.2 - 1.4"},
+		{"1.0.0 || >=1.3.0", "1.3.0", "1.0.0 || >=1.3.0"},
+		// Wildcards that exclude it keep their shape.
+		{"1.2.x", "1.3.0", "1.3.x"},
+		{"v1.2.*", "1.3.0", "v1.3.*"},
+		{"1.2", "1.3.0", "1.3"},
+		// A "v" on both sides is not doubled.
+		{"v1.2.0", "v1.3.0", "v1.3.0"},
+		{"1.2.0", "v1.3.0", "v1.3.0"},
+	}
+	for _, tt := range tests {
+		got, err := pinVersion(tt.old, tt.version)
+		if err != nil {
+			t.Errorf("%q: %v", tt.old, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("pinVersion(%q, %q) = %q, want %q", tt.old, tt.version, got, tt.want)
+		}
+	}
+
+	for _, old := range []string{">=1.0.0 <1.3.0", "1.2.0 - 1.2.9", "<=1.2.5", "!=1.3.0", ">=1.0.0 <=x.y"} {
+		if got, err := pinVersion(old, "1.3.0"); err == nil {
+			t.Errorf("%q: expected an error, got %q", old, got)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		want                bool
+	}{
+		{"^1.2.0", "1.9.9", true},
+		{"^1.2.0", "2.0.0", false},
+		{"^1.2.0", "2.0.0-rc.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<1.2.0", "1.2.0-rc.1", true},
+		{"1.2.x", "1.2.7", true},
+		{"1.2.x", "1.3.0", false},
+		{"!=1.2.0", "1.2.0", false},
+		{"1.0 - 1.2", "1.2.5", true},
+		{"1.0 - 1.2", "1.3.0", false},
+		{">=1.0.0 <2.0.0 || ^3.0.0", "3.1.0", true},
+		{"", "1.0.0", true},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Satisfies(tt.constraint, v)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfies(%q, %s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+	if _, err := Satisfies(">=one", Version{}); err == nil {
+		t.Error("expected an error for an invalid constraint")
+	}
+}
@@ -0,0 +1,302 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scalarEdit replaces the value of one scalar node in the raw file.
+type scalarEdit struct {
+	node  *yaml.Node
+	value string
+}
+
+// replaceScalars rewrites the bytes of each edited scalar in place, keeping
+// its quoting style, then re-parses the chart. Nothing outside the scalars'
+// own bytes changes.
+func (c *Chart) replaceScalars(edits []scalarEdit) error {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, e := range edits {
+		if e.node.Kind != yaml.ScalarNode {
+			return fmt.Errorf("%s:%d: expected a scalar value", c.Path, e.node.Line)
+		}
+		start, err := c.offset(e.node.Line, e.node.Column)
+		if err != nil {
+			return err
+		}
+		flow := c.inFlow(e.node)
+		end, err := scalarEnd(c.raw, start, e.node, flow)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", c.Path, e.node.Line, err)
+		}
+		text := renderScalar(e.value, e.node.Style)
+		if flow && e.node.Style == 0 && strings.ContainsAny(e.value, flowIndicators) {
+			text = doubleQuote(e.value)
+		}
+		spans = append(spans, span{start: start, end: end, text: text})
+	}
+
+	// Apply from the end of the file backwards so earlier offsets stay valid.
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	out := append([]byte(nil), c.raw...)
+	for _, s := range spans {
+		out = append(out[:s.start], append([]byte(s.text), out[s.end:]...)...)
+	}
+	return c.parse(out)
+}
+
+// insertAfter adds a "name: value" line directly below the line holding
+// the value of key, at the same indentation as key, and re-parses the chart.
+func (c *Chart) insertAfter(key *yaml.Node, name, value string) error {
+	_, val := c.fieldFor(key)
+	line := key.Line
+	if val != nil && val.Line > line {
+		line = val.Line
+	}
+
+	eol := lineEnding(c.raw)
+	insertAt := len(c.raw)
+	if start, err := c.offset(line+1, 1); err == nil {
+		insertAt = start
+	}
+
+	var b strings.Builder
+	if insertAt > 0 && c.raw[insertAt-1] != '\n' {
+		b.WriteString(eol)
+	}
+	b.WriteString(strings.Repeat(" ", key.Column-1))
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(renderScalar(value, yaml.DoubleQuotedStyle))
+	b.WriteString(eol)
+
+	out := append([]byte(nil), c.raw[:insertAt]...)
+	out = append(out, b.String()...)
+	out = append(out, c.raw[insertAt:]...)
+	return c.parse(out)
+}
+
+// fieldFor returns the key/value pair in the parent mapping of key.
+func (c *Chart) fieldFor(key *yaml.Node) (*yaml.Node, *yaml.Node) {
+	var walk func(n *yaml.Node) (*yaml.Node, *yaml.Node)
+	walk = func(n *yaml.Node) (*yaml.Node, *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i < len(n.Content)-1; i += 2 {
+				if n.Content[i] == key {
+					return n.Content[i], n.Content[i+1]
+				}
+			}
+		}
+		for _, child := range n.Content {
+			if k, v := walk(child); k != nil {
+				return k, v
+			}
+		}
+		return nil, nil
+	}
+	return walk(&c.doc)
+}
+
+// inFlow reports whether node sits inside a flow mapping or sequence,
+// such as {name: common, version: 1.2.0} or [a, b].
+func (c *Chart) inFlow(node *yaml.Node) bool {
+	var walk func(n *yaml.Node, flow bool) (found, inFlow bool)
+	walk = func(n *yaml.Node, flow bool) (bool, bool) {
+		if n == node {
+			return true, flow
+		}
+		flow = flow || n.Style&yaml.FlowStyle != 0
+		for _, child := range n.Content {
+			if found, f := walk(child, flow); found {
+				return true, f
+			}
+		}
+		return false, false
+	}
+	_, flow := walk(&c.doc, false)
+	return flow
+}
+
+// flowIndicators end a plain scalar inside a flow collection.
+const flowIndicators = ",[]{}"
+
+// offset converts a 1-based line and column (as reported by yaml.v3, in
+// characters) to a byte offset in the raw file.
+func (c *Chart) offset(line, column int) (int, error) {
+	pos := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(c.raw[pos:], '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("%s: line %d out of range", c.Path, line)
+		}
+		pos += i + 1
+	}
+	if pos >= len(c.raw) && column > 1 {
+		return 0, fmt.Errorf("%s: line %d out of range", c.Path, line)
+	}
+	for col := 1; col < column; col++ {
+		if pos >= len(c.raw) || c.raw[pos] == '\n' {
+			return 0, fmt.Errorf("%s: column %d out of range on line %d", c.Path, column, line)
+		}
+		_, size := utf8.DecodeRune(c.raw[pos:])
+		pos += size
+	}
+	return pos, nil
+}
+
+// scalarEnd returns the byte offset just past the scalar starting at start.
+// Inside a flow collection a plain scalar also ends at a flow indicator.
+func scalarEnd(raw []byte, start int, node *yaml.Node, flow bool) (int, error) {
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		for i := start + 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated double-quoted scalar")
+	case yaml.SingleQuotedStyle:
+		for i := start + 1; i < len(raw); i++ {
+			if raw[i] != '\'' {
+				continue
+			}
+			if i+1 < len(raw) && raw[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+		return 0, fmt.Errorf("unterminated single-quoted scalar")
+	case 0:
+		if strings.Contains(node.Value, "\n") {
+			return 0, fmt.Errorf("multi-line plain scalars cannot be edited in place")
+		}
+		end := start
+		for end < len(raw) && raw[end] != '\n' && raw[end] != '\r' {
+			if raw[end] == '#' && end > start && (raw[end-1] == ' ' || raw[end-1] == '\t') {
+				break
+			}
+			if flow && strings.IndexByte(flowIndicators, raw[end]) >= 0 {
+				break
+			}
+			end++
+		}
+		for end > start && (raw[end-1] == ' ' || raw[end-1] == '\t') {
+			end--
+		}
+		return end, nil
+	}
+	return 0, fmt.Errorf("block scalars cannot be edited in place")
+}
+
+func isBlock(n *yaml.Node) bool {
+	return n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+}
+
+// blockEnd returns the byte offset just past the last content line of the
+// block scalar whose indicator (| or >) starts at start. Content lines are
+// those indented deeper than keyIndent; trailing blank lines are left out
+// so the blank lines around the entry survive an edit.
+func blockEnd(raw []byte, start, keyIndent int) int {
+	end := start
+	for end < len(raw) && raw[end] != '\n' && raw[end] != '\r' {
+		end++
+	}
+	pos := end
+	for pos < len(raw) {
+		lineStart := pos
+		if raw[pos] == '\r' {
+			lineStart++
+		}
+		lineStart++ // past '\n'
+		if lineStart > len(raw) {
+			break
+		}
+		lineEnd := lineStart
+		for lineEnd < len(raw) && raw[lineEnd] != '\n' {
+			lineEnd++
+		}
+		line := strings.TrimRight(string(raw[lineStart:lineEnd]), "\r")
+		if strings.TrimSpace(line) != "" {
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if indent <= keyIndent {
+				break
+			}
+			end = lineStart + len(line)
+		}
+		pos = lineEnd
+	}
+	return end
+}
+
+// renderBlock formats value as a literal block scalar whose lines are
+// indented by indent spaces. The result has no trailing line ending.
+func renderBlock(value string, indent int, eol string) string {
+	lines := strings.Split(strings.TrimRight(value, "\n"), "\n")
+	var b strings.Builder
+	b.WriteString("|")
+	pad := strings.Repeat(" ", indent)
+	for _, l := range lines {
+		b.WriteString(eol)
+		if l != "" {
+			b.WriteString(pad)
+			b.WriteString(l)
+		}
+	}
+	return b.String()
+}
+
+// renderScalar formats value in the given YAML style. Plain values that
+// would not survive a round trip as plain scalars are double-quoted.
+func renderScalar(value string, style yaml.Style) string {
+	switch style {
+	case yaml.SingleQuotedStyle:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case yaml.DoubleQuotedStyle:
+		return doubleQuote(value)
+	}
+	if !plainSafe(value) {
+		return doubleQuote(value)
+	}
+	return value
+}
+
+func doubleQuote(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(value) + `"`
+}
+
+// plainSafe reports whether value can be written as a plain scalar and
+// read back as the same string.
+func plainSafe(value string) bool {
+	if value == "" || strings.TrimSpace(value) != value || strings.ContainsAny(value, "#\n\r\t") {
+		return false
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &node); err != nil || len(node.Content) == 0 {
+		return false
+	}
+	n := node.Content[0]
+	return n.Kind == yaml.ScalarNode && n.Style == 0 && n.Value == value
+}
+
+// lineEnding returns the line ending used by raw: "\r\n" if its first line
+// ends that way, otherwise "\n".
+func lineEnding(raw []byte) string {
+	if i := bytes.IndexByte(raw, '\n'); i > 0 && raw[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,178 @@
+package chart
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadRaw writes content to a Chart.yaml in a temp dir and loads it.
+func loadRaw(t *testing.T, content string) *Chart {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Chart.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("file mismatch\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+}
+
+func TestSetVersionPreservesBytes(t *testing.T) {
+	before := `apiVersion: v2
+name:   spaced-chart   # odd spacing is kept
+
+description: 'single quoted'
+keywords: [a, "b", c]
+# The chart version
+version: 1.0.0    # bumped by helmver
+appVersion: "1.0"
+maintainers:
+    - name: deep-indent
+      email: x@example.com
+`
+	c := loadRaw(t, before)
+	if err := c.SetVersion("1.10.0"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, c.Path, strings.Replace(before, "version: 1.0.0    #", "version: 1.10.0    #", 1))
+}
+
+func TestSetVersionQuotingStyles(t *testing.T) {
+	tests := []struct {
+		name, before, want string
+	}{
+		{"double", "name: x\nversion: \"1.0.0\"\n", "name: x\nversion: \"1.0.1\"\n"},
+		{"single", "name: x\nversion: '1.0.0'\n", "name: x\nversion: '1.0.1'\n"},
+		{"plain", "name: x\nversion: 1.0.0\n", "name: x\nversion: 1.0.1\n"},
+		{"no trailing newline", "name: x\nversion: 1.0.0", "name: x\nversion: 1.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := loadRaw(t, tt.before)
+			if err := c.SetVersion("1.0.1"); err != nil {
+				t.Fatal(err)
+			}
+			assertFile(t, c.Path, tt.want)
+		})
+	}
+}
+
+func TestSetVersionPreservesCRLF(t *testing.T) {
+	before := "apiVersion: v2\r\nname: crlf\r\n\r\n# comment\r\nversion: 0.1.0\r\ntype: application\r\n"
+	c := loadRaw(t, before)
+	if err := c.SetVersion("0.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, c.Path, strings.Replace(before, "0.1.0", "0.2.0", 1))
+
+	if err := c.SetAppVersion("9.9"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, c.Path, "apiVersion: v2\r\nname: crlf\r\n\r\n# comment\r\nversion: 0.2.0\r\nappVersion: \"9.9\"\r\ntype: application\r\n")
+}
+
+func TestSetVersionRepeatedEdits(t *testing.T) {
+	before := "name: x\nversion: 1.0.0 # v\nappVersion: 'a'\n"
+	c := loadRaw(t, before)
+	for _, v := range []string{"1.0.1", "1.0.10", "2.0.0-rc.1"} {
+		if err := c.SetVersion(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.SetAppVersion("it's"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, c.Path, "name: x\nversion: 2.0.0-rc.1 # v\nappVersion: 'it''s'\n")
+	if c.Version != "2.0.0-rc.1" || c.AppVersion != "it's" {
+		t.Errorf("fields not refreshed: %q %q", c.Version, c.AppVersion)
+	}
+}
+
+func TestSetDependencyVersionPreservesBytes(t *testing.T) {
+	dir := t.TempDir()
+	before := "apiVersion: v2\nname: web\nversion: 0.1.0\ndependencies:\n- name: common\n  version: \"1.2.0\"   # pinned\n  repository: file://../common\n- {name: redis, version: 17.0.0, repository: https://charts.bitnami.com/bitnami}\n"
+	path := filepath.Join(dir, "web", "Chart.yaml")
+	web := writeChart(t, path, before)
+
+	if err := web.SetDependencyVersion(filepath.Join(dir, "common"), "1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, strings.Replace(before, `"1.2.0"`, `"1.3.0"`, 1))
+}
+
+func TestSetDependencyVersionFlowStyle(t *testing.T) {
+	tests := []struct {
+		name, before, want string
+	}{
+		{
+			"flow mapping",
+			"name: web\nversion: 0.1.0\ndependencies:\n  - {name: common, version: 1.2.0, repository: file://../common}\n",
+			"name: web\nversion: 0.1.0\ndependencies:\n  - {name: common, version: 1.3.0, repository: file://../common}\n",
+		},
+		{
+			"flow sequence",
+			"name: web\nversion: 0.1.0\ndependencies: [{name: common, repository: file://../common, version: 1.2.0}]  # local\n",
+			"name: web\nversion: 0.1.0\ndependencies: [{name: common, repository: file://../common, version: 1.3.0}]  # local\n",
+		},
+		{
+			"multi-line flow",
+			"name: web\nversion: 0.1.0\ndependencies: [\n  {name: common, version: 1.2.0 ,repository: file://../common},\n]\n",
+			"name: web\nversion: 0.1.0\ndependencies: [\n  {name: common, version: 1.3.0 ,repository: file://../common},\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "web", "Chart.yaml")
+			web := writeChart(t, path, tt.before)
+			if err := web.SetDependencyVersion(filepath.Join(dir, "common"), "1.3.0"); err != nil {
+				t.Fatal(err)
+			}
+			assertFile(t, path, tt.want)
+			if web.Dependencies[0].Version != "1.3.0" {
+				t.Errorf("dependency not refreshed: %+v", web.Dependencies[0])
+			}
+		})
+	}
+}
+
+func TestReplaceScalarsFlowSequence(t *testing.T) {
+	c := loadRaw(t, "name: x\nversion: 1.0.0\nkeywords: [a, b,c]\n")
+	keywords := c.doc.Content[0].Content[5]
+	err := c.replaceScalars([]scalarEdit{
+		{node: keywords.Content[1], value: "bee"},
+		{node: keywords.Content[2], value: "c,d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name: x\nversion: 1.0.0\nkeywords: [a, bee,\"c,d\"]\n"; string(c.raw) != want {
+		t.Errorf("got %q, want %q", c.raw, want)
+	}
+}
+
+func TestRenderScalarQuotesUnsafePlain(t *testing.T) {
+	if got := renderScalar("1.2.3", 0); got != "1.2.3" {
+		t.Errorf("got %q", got)
+	}
+	for _, v := range []string{"", "a: b", "#x", "[x]", " x"} {
+		if got := renderScalar(v, 0); !strings.HasPrefix(got, `"`) {
+			t.Errorf("renderScalar(%q) = %q, want double-quoted", v, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned by Index.Resolve when no chart matches a reference.
+var ErrNotFound = errors.New("chart not found")
+
+// AmbiguousError is returned by Index.Resolve when a bare chart name
+// matches more than one chart.
+type AmbiguousError struct {
+	Name string
+	IDs  []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("chart name %q is ambiguous: it matches %s; reference the chart by path instead",
+		e.Name, strings.Join(e.IDs, ", "))
+}
+
+// Index looks charts up by name or by path. Every chart gets a stable ID:
+// its directory relative to the index root, slash-separated ("charts/api",
+// or "." for a chart at the root).
+type Index struct {
+	root   string
+	charts []*Chart
+	byName map[string][]*Chart
+	byID   map[string]*Chart
+}
+
+// NewIndex builds an index over charts and sets each chart's ID relative
+// to root.
+func NewIndex(root string, charts []*Chart) *Index {
+	x := &Index{
+		root:   root,
+		charts: charts,
+		byName: make(map[string][]*Chart),
+		byID:   make(map[string]*Chart),
+	}
+	for _, c := range charts {
+		c.ID = chartID(root, c.Dir)
+		x.byName[c.Name] = append(x.byName[c.Name], c)
+		x.byID[c.ID] = c
+	}
+	return x
+}
+
+// Charts returns the indexed charts in discovery order.
+func (x *Index) Charts() []*Chart {
+	return x.charts
+}
+
+// Resolve finds the chart a changeset or flag refers to. A reference that
+// contains a path separator (or starts with ".") is matched against chart
+// IDs; anything else is a chart name, which must be unique.
+func (x *Index) Resolve(ref string) (*Chart, error) {
+	if isPathRef(ref) {
+		id := filepath.ToSlash(filepath.Clean(strings.TrimSuffix(strings.TrimSuffix(ref, "/Chart.yaml"), "/Chart.yml")))
+		if c, ok := x.byID[id]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: no chart at path %q", ErrNotFound, ref)
+	}
+
+	matches := x.byName[ref]
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: no chart named %q", ErrNotFound, ref)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, c := range matches {
+		ids[i] = c.ID
+	}
+	sort.Strings(ids)
+	return nil, &AmbiguousError{Name: ref, IDs: ids}
+}
+
+// Label returns the reference to show for a chart and to write into new
+// changeset files: its name when that is unique, otherwise its ID.
+func (x *Index) Label(c *Chart) string {
+	if len(x.byName[c.Name]) > 1 {
+		return c.ID
+	}
+	return c.Name
+}
+
+func isPathRef(ref string) bool {
+	return strings.ContainsAny(ref, `/\`) || strings.HasPrefix(ref, ".")
+}
+
+func chartID(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(rel)
+}
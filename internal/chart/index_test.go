@@ -0,0 +1,65 @@
+package chart
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexResolve(t *testing.T) {
+	root := t.TempDir()
+	prod := writeChart(t, filepath.Join(root, "envs", "prod", "app", "Chart.yaml"), "name: app\nversion: 1.0.0\n")
+	stage := writeChart(t, filepath.Join(root, "envs", "stage", "app", "Chart.yaml"), "name: app\nversion: 1.0.0\n")
+	api := writeChart(t, filepath.Join(root, "api", "Chart.yaml"), "name: api\nversion: 1.0.0\n")
+	top := writeChart(t, filepath.Join(root, "Chart.yaml"), "name: top\nversion: 1.0.0\n")
+
+	idx := NewIndex(root, []*Chart{prod, stage, api, top})
+
+	if prod.ID != "envs/prod/app" || api.ID != "api" || top.ID != "." {
+		t.Fatalf("unexpected IDs: %q %q %q", prod.ID, api.ID, top.ID)
+	}
+
+	tests := []struct {
+		ref  string
+		want *Chart
+	}{
+		{"api", api},
+		{"./api", api},
+		{"envs/prod/app", prod},
+		{"envs/stage/app/", stage},
+		{"./envs/stage/app/Chart.yaml", stage},
+		{".", top},
+		{"top", top},
+	}
+	for _, tt := range tests {
+		got, err := idx.Resolve(tt.ref)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tt.ref, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %s, want %s", tt.ref, got.ID, tt.want.ID)
+		}
+	}
+
+	_, err := idx.Resolve("app")
+	var amb *AmbiguousError
+	if !errors.As(err, &amb) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "envs/prod/app, envs/stage/app") {
+		t.Errorf("error should list matching paths: %v", err)
+	}
+
+	if _, err := idx.Resolve("ghost"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := idx.Resolve("envs/dev/app"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown path, got %v", err)
+	}
+
+	if idx.Label(prod) != "envs/prod/app" || idx.Label(api) != "api" {
+		t.Errorf("unexpected labels: %q %q", idx.Label(prod), idx.Label(api))
+	}
+}
@@ -0,0 +1,329 @@
+package chart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultPreID is the pre-release identifier used by premajor, preminor and
+// prepatch bumps when none is given (1.4.0 -> 1.5.0-rc.0).
+const DefaultPreID = "rc"
+
+// ValidatePreID checks a pre-release identifier given with --preid. It
+// follows the SemVer rules for pre-release identifiers, so that the
+// versions it produces parse again: "rc", "beta" or "alpha.1" are valid,
+// "", "rc 1" and "01" are not.
+func ValidatePreID(preid string) error {
+	if preid == "" {
+		return fmt.Errorf("invalid pre-release identifier: must not be empty")
+	}
+	if _, err := splitIdentifiers(preid, true); err != nil {
+		return fmt.Errorf("invalid pre-release identifier %q: %w", preid, err)
+	}
+	return nil
+}
+
+// BumpKinds lists every supported bump type, lowest precedence first.
+// The order is used when aggregating several changesets for one chart.
+var BumpKinds = []string{
+	"prerelease",
+	"release",
+	"prepatch",
+	"patch",
+	"preminor",
+	"minor",
+	"premajor",
+	"major",
+}
+
+// ValidBump reports whether bump is a supported bump type.
+func ValidBump(bump string) bool {
+	return BumpRank(bump) > 0
+}
+
+// BumpRank orders bump types so that the highest one wins when several
+// changesets target the same chart. Unknown bump types rank 0.
+func BumpRank(bump string) int {
+	for i, k := range BumpKinds {
+		if k == bump {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Version is a parsed SemVer 2.0 version. Prefix holds an optional leading
+// "v", which is preserved when the version is formatted again.
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      []string
+}
+
+// ParseVersion parses a SemVer 2.0 string with an optional "v" prefix.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest := s
+	if strings.HasPrefix(rest, "v") {
+		v.Prefix = "v"
+		rest = rest[1:]
+	}
+
+	if i := strings.Index(rest, "+"); i >= 0 {
+		build := rest[i+1:]
+		rest = rest[:i]
+		ids, err := splitIdentifiers(build, false)
+		if err != nil {
+			return Version{}, fmt.Errorf("version %q has invalid build metadata: %w", s, err)
+		}
+		v.Build = ids
+	}
+	if i := strings.Index(rest, "-"); i >= 0 {
+		pre := rest[i+1:]
+		rest = rest[:i]
+		ids, err := splitIdentifiers(pre, true)
+		if err != nil {
+			return Version{}, fmt.Errorf("version %q has invalid pre-release: %w", s, err)
+		}
+		v.Prerelease = ids
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("version %q is not valid semver (expected X.Y.Z)", s)
+	}
+	nums := make([]int, 3)
+	for i, name := range []string{"major", "minor", "patch"} {
+		n, err := parseNumeric(parts[i])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid %s version %q: %w", name, parts[i], err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// String formats the version, including prefix, pre-release and build metadata.
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version carries a pre-release suffix.
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 following SemVer 2.0 precedence rules.
+// Build metadata and the "v" prefix are ignored.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Prerelease) - len(o.Prerelease))
+}
+
+// Bump returns the next version for the given bump type. preid names the
+// pre-release identifier for premajor, preminor, prepatch, and for
+// prerelease when the current version is not already a pre-release.
+// Build metadata is always dropped.
+func (v Version) Bump(bump, preid string) (Version, error) {
+	if preid == "" {
+		preid = DefaultPreID
+	}
+	if err := ValidatePreID(preid); err != nil {
+		return Version{}, err
+	}
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	switch bump {
+	case "major":
+		// 2.0.0-rc.1 -> 2.0.0: the pre-release already was the next major.
+		if !(v.IsPrerelease() && v.Minor == 0 && v.Patch == 0) {
+			next.Major++
+		}
+		next.Minor, next.Patch = 0, 0
+	case "minor":
+		if !(v.IsPrerelease() && v.Patch == 0) {
+			next.Minor++
+		}
+		next.Patch = 0
+	case "patch":
+		if !v.IsPrerelease() {
+			next.Patch++
+		}
+	case "premajor":
+		next.Major++
+		next.Minor, next.Patch = 0, 0
+		next.Prerelease = []string{preid, "0"}
+	case "preminor":
+		next.Minor++
+		next.Patch = 0
+		next.Prerelease = []string{preid, "0"}
+	case "prepatch":
+		next.Patch++
+		next.Prerelease = []string{preid, "0"}
+	case "prerelease":
+		if !v.IsPrerelease() {
+			next.Patch++
+			next.Prerelease = []string{preid, "0"}
+			break
+		}
+		next.Prerelease = incrementPrerelease(v.Prerelease)
+	case "release":
+		if !v.IsPrerelease() {
+			return Version{}, fmt.Errorf("version %s is not a pre-release", v)
+		}
+	default:
+		return Version{}, fmt.Errorf("unknown bump type %q", bump)
+	}
+	return next, nil
+}
+
+// InferBump names the bump that leads from v to next, which must be
+// greater than v: "major", "minor" or "patch" for the highest component
+// that changed, with a "pre" prefix when next is a pre-release, and
+// "prerelease" or "release" when only the pre-release changed.
+//
+// adjacent reports whether a single bump of any kind can produce next
+// (1.2.0 -> 1.3.0), as opposed to skipping versions (1.2.0 -> 3.0.0).
+// Pre-release identifiers are not compared, so 1.2.0 -> 2.0.0-beta.1 is
+// adjacent.
+func InferBump(v, next Version) (bump string, adjacent bool) {
+	pre := ""
+	if next.IsPrerelease() {
+		pre = "pre"
+	}
+	switch {
+	case next.Major != v.Major:
+		bump = pre + "major"
+	case next.Minor != v.Minor:
+		bump = pre + "minor"
+	case next.Patch != v.Patch:
+		bump = pre + "patch"
+	case next.IsPrerelease():
+		bump = "prerelease"
+	default:
+		bump = "release"
+	}
+
+	for _, kind := range BumpKinds {
+		cand, err := v.Bump(kind, DefaultPreID)
+		if err != nil || cand.IsPrerelease() != next.IsPrerelease() {
+			continue
+		}
+		if cand.Major == next.Major && cand.Minor == next.Minor && cand.Patch == next.Patch {
+			return bump, true
+		}
+	}
+	return bump, false
+}
+
+// incrementPrerelease bumps the right-most numeric identifier, or appends
+// ".0" when there is none (rc -> rc.0, rc.1 -> rc.2).
+func incrementPrerelease(ids []string) []string {
+	out := append([]string(nil), ids...)
+	for i := len(out) - 1; i >= 0; i-- {
+		if n, err := parseNumeric(out[i]); err == nil {
+			out[i] = strconv.Itoa(n + 1)
+			return out
+		}
+	}
+	return append(out, "0")
+}
+
+func splitIdentifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+		for _, r := range id {
+			if !isIdentChar(r) {
+				return nil, fmt.Errorf("invalid character %q in %q", r, id)
+			}
+		}
+		if prerelease && isDigits(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier %q has a leading zero", id)
+		}
+	}
+	return ids, nil
+}
+
+func parseNumeric(s string) (int, error) {
+	if !isDigits(s) {
+		return 0, fmt.Errorf("not a number")
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("leading zero")
+	}
+	return strconv.Atoi(s)
+}
+
+func compareIdentifier(a, b string) int {
+	an, aErr := parseNumeric(a)
+	bn, bErr := parseNumeric(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(an - bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentChar(r rune) bool {
+	return r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
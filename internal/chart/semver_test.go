@@ -0,0 +1,164 @@
+package chart
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"1.2.3", false},
+		{"v1.2.3", false},
+		{"1.2.3-rc.1", false},
+		{"1.2.3-alpha-1.x", false},
+		{"1.2.3+build.5", false},
+		{"1.2.3-rc.1+sha.abc123", false},
+		{"1.2.3-01", true},
+		{"1.2.3-rc..1", true},
+		{"1.2.3+", true},
+		{"1.2.3-rc_1", true},
+		{"1.2", true},
+		{"a.b.c", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := ParseVersion(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.String() != tt.in {
+				t.Errorf("round trip: got %q, want %q", v.String(), tt.in)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Ordered lowest to highest, from the SemVer 2.0 spec.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := ParseVersion(ordered[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(ordered[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+
+	a, _ := ParseVersion("v1.0.0+build.1")
+	b, _ := ParseVersion("1.0.0+build.2")
+	if a.Compare(b) != 0 {
+		t.Error("prefix and build metadata should not affect precedence")
+	}
+}
+
+func TestBumpVersionPreCustomID(t *testing.T) {
+	got, err := BumpVersionPre("1.4.0", "preminor", "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "1.5.0-beta.0" {
+		t.Errorf("got %q, want 1.5.0-beta.0", got)
+	}
+
+	// An existing pre-release keeps its own identifier.
+	got, err = BumpVersionPre("1.5.0-beta.0", "prerelease", "rc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "1.5.0-beta.1" {
+		t.Errorf("got %q, want 1.5.0-beta.1", got)
+	}
+}
+
+func TestValidatePreID(t *testing.T) {
+	for _, id := range []string{"rc", "beta", "alpha.1", "pre-release", "0"} {
+		if err := ValidatePreID(id); err != nil {
+			t.Errorf("%q: %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "rc 1", "01", "rc..1", "rc_1", "beta.01"} {
+		if err := ValidatePreID(id); err == nil {
+			t.Errorf("%q: expected an error", id)
+		}
+	}
+	if _, err := BumpVersionPre("1.4.0", "preminor", "rc 1"); err == nil {
+		t.Error("expected BumpVersionPre to reject an invalid preid")
+	}
+}
+
+func TestBumpRank(t *testing.T) {
+	if BumpRank("major") <= BumpRank("minor") || BumpRank("minor") <= BumpRank("patch") {
+		t.Error("expected major > minor > patch")
+	}
+	if BumpRank("patch") <= BumpRank("prerelease") {
+		t.Error("expected patch > prerelease")
+	}
+	if BumpRank("bogus") != 0 || ValidBump("bogus") {
+		t.Error("unknown bump should rank 0")
+	}
+}
+
+func TestInferBump(t *testing.T) {
+	tests := []struct {
+		from, to string
+		bump     string
+		adjacent bool
+	}{
+		{"1.2.3", "1.2.4", "patch", true},
+		{"1.2.3", "1.3.0", "minor", true},
+		{"1.2.3", "2.0.0", "major", true},
+		{"1.2.0", "3.0.0", "major", false},
+		{"1.2.0", "1.4.0", "minor", false},
+		{"1.2.3", "1.3.1", "minor", false},
+		{"1.2.3", "1.2.5", "patch", false},
+		{"1.2.3", "2.0.0-beta.1", "premajor", true},
+		{"1.2.3", "1.3.0-rc.0", "preminor", true},
+		{"1.2.3", "1.2.4-rc.0", "prepatch", true},
+		{"2.0.0-rc.1", "2.0.0-rc.2", "prerelease", true},
+		{"2.0.0-rc.1", "2.0.0", "release", true},
+		{"1.3.0-rc.1", "1.3.0", "release", true},
+		{"v1.2.3", "v1.3.0", "minor", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			from, err := ParseVersion(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := ParseVersion(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bump, adjacent := InferBump(from, to)
+			if bump != tt.bump || adjacent != tt.adjacent {
+				t.Errorf("InferBump = %q, %v; want %q, %v", bump, adjacent, tt.bump, tt.adjacent)
+			}
+		})
+	}
+}
@@ -70,11 +70,11 @@ func TestIntegration_MonorepoStaleness(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		isStale, err := git.IsStale(dir, c.Dir, c.Path, "base", c.Version)
+		res, err := git.IsStale(dir, c.Dir, c.Path, "base", c.Version)
 		if err != nil {
 			t.Fatal(err)
 		}
-		c.Stale = isStale
+		c.Stale = res.Stale
 		if c.Stale {
 			staleCount++
 			if c.Name != "api" {
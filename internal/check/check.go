@@ -9,7 +9,9 @@ import (
 
 	"github.com/jordan-simonovski/helmver/internal/changeset"
 	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/config"
 	"github.com/jordan-simonovski/helmver/internal/git"
+	"github.com/jordan-simonovski/helmver/internal/ignore"
 )
 
 // ErrNotGitRepo is returned when staleness cannot be determined outside a git repo.
@@ -18,17 +20,35 @@ var ErrNotGitRepo = errors.New("not a git repository")
 // ChartResult holds the check outcome for one chart.
 type ChartResult struct {
 	Name         string `json:"name"`
+	ID           string `json:"id"` // chart directory relative to the changeset root
 	Version      string `json:"version"`
 	Dir          string `json:"dir"`
 	HasChangeset bool   `json:"hasChangeset,omitempty"`
+	// BaseVersion is the chart version at the base ref.
+	BaseVersion string `json:"baseVersion,omitempty"`
+	// ReleaseTag is the release tag the chart was compared with, when
+	// comparing with release tags.
+	ReleaseTag string `json:"releaseTag,omitempty"`
+	// ChangedFiles are the files, relative to the repository root, that
+	// changed since MergeBase and made the chart stale.
+	ChangedFiles []string `json:"changedFiles,omitempty"`
+	MergeBase    string   `json:"mergeBase,omitempty"`
+	// Bump is the bump kind inferred from BaseVersion to Version, for
+	// charts whose version changed.
+	Bump string `json:"bump,omitempty"`
 }
 
 // Result is the outcome of a helmver check run.
 type Result struct {
 	StaleCharts   []ChartResult
 	CoveredCharts []ChartResult
-	Changesets    []*changeset.File
-	AllUpToDate   bool
+	// BumpedCharts are the charts whose version differs from the base ref.
+	BumpedCharts []ChartResult
+	Changesets   []*changeset.File
+	// Findings are rule violations, such as a lowered version. Errors fail
+	// the check even when no chart is stale; see HasErrors.
+	Findings    []Finding
+	AllUpToDate bool
 }
 
 // Options configures a check run.
@@ -38,6 +58,13 @@ type Options struct {
 	Exclude          []string
 	RequireChangeset bool
 	ChangesetRoot    string
+	Config           *config.Config // nil means the default configuration
+	// Mode selects the changes to check: committed ones (the default),
+	// or the staged or working-tree changes on top of them.
+	Mode git.Mode
+	// TagPattern compares each chart with its last release tag instead of
+	// Base; it overrides staleness.tagPattern in Config.
+	TagPattern string
 }
 
 // Run discovers charts, detects staleness, and optionally filters by changesets.
@@ -71,55 +98,105 @@ func Run(opts Options) (*Result, error) {
 		baseRef = git.ResolveBase(repoRoot)
 	}
 
-	if !git.RefExists(repoRoot, baseRef) {
-		fetchHint := baseRef
-		if strings.HasPrefix(baseRef, "origin/") {
-			fetchHint = "git fetch origin " + strings.TrimPrefix(baseRef, "origin/") + " --depth=1"
+	changesetRoot := opts.ChangesetRoot
+	if changesetRoot == "" {
+		changesetRoot, err = os.Getwd()
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("base ref %q not found; fetch it first (e.g. %s) or set --base", baseRef, fetchHint)
 	}
 
-	var stale []*chart.Chart
+	var all []*chart.Chart
 	for _, path := range charts {
 		c, err := chart.Load(path)
 		if err != nil {
 			return nil, fmt.Errorf("loading %s: %w", path, err)
 		}
+		all = append(all, c)
+	}
+	idx := chart.NewIndex(changesetRoot, all)
 
-		isStale, err := git.IsStale(repoRoot, c.Dir, c.Path, baseRef, c.Version)
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	refs, err := ChartRefs(idx, all, cfg.Staleness)
+	if err != nil {
+		return nil, err
+	}
+	needBase := true
+	if p := firstNonEmpty(opts.TagPattern, cfg.Staleness.TagPattern); p != "" {
+		pattern, err := git.ParseTagPattern(p)
 		if err != nil {
-			return nil, fmt.Errorf("checking %s: %w", c.Name, err)
+			return nil, err
 		}
-		if isStale {
-			stale = append(stale, c)
+		if needBase, err = ReleaseTags(repoRoot, pattern, all, refs); err != nil {
+			return nil, err
 		}
 	}
+	if needBase && !git.RefExists(repoRoot, baseRef) {
+		fetchHint := baseRef
+		if strings.HasPrefix(baseRef, "origin/") {
+			fetchHint = "git fetch origin " + strings.TrimPrefix(baseRef, "origin/") + " --depth=1"
+		}
+		return nil, fmt.Errorf("base ref %q not found; fetch it first (e.g. %s) or set --base", baseRef, fetchHint)
+	}
 
-	changesetRoot := opts.ChangesetRoot
-	if changesetRoot == "" {
-		changesetRoot, err = os.Getwd()
-		if err != nil {
-			return nil, err
+	results, err := git.StaleCharts(repoRoot, baseRef, opts.Mode, refs)
+	if err != nil {
+		return nil, fmt.Errorf("checking charts: %w", err)
+	}
+	var stale []*chart.Chart
+	details := make(map[*chart.Chart]git.StaleResult)
+	inferred := make(map[*chart.Chart]string)
+	tags := make(map[*chart.Chart]string)
+	for i, c := range all {
+		res := results[i]
+		details[c] = res
+		tags[c] = refs[i].Base
+		if res.Stale {
+			stale = append(stale, c)
+		}
+		if res.BaseVersion != "" && res.BaseVersion != res.Version {
+			bump, findings := versionRules(idx.Label(c), res.BaseVersion, res.Version, cfg.Versions)
+			inferred[c] = bump
+			result.BumpedCharts = append(result.BumpedCharts, ChartResult{
+				Name:        c.Name,
+				ID:          c.ID,
+				Version:     res.Version,
+				Dir:         c.Dir,
+				BaseVersion: res.BaseVersion,
+				Bump:        bump,
+			})
+			result.Findings = append(result.Findings, findings...)
 		}
 	}
 
-	var covered map[string]bool
+	var declared map[string]string
 	if opts.RequireChangeset && len(stale) > 0 {
 		files, err := changeset.Discover(changesetRoot)
 		if err != nil {
 			return nil, fmt.Errorf("reading changesets: %w", err)
 		}
 		result.Changesets = files
-		covered = changeset.ChartNames(files)
+		declared, err = declaredBumps(idx, files)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	for _, c := range stale {
 		cr := ChartResult{
-			Name:    c.Name,
-			Version: c.Version,
-			Dir:     c.Dir,
+			Name:         c.Name,
+			ID:           c.ID,
+			Version:      details[c].Version,
+			Dir:          c.Dir,
+			BaseVersion:  details[c].BaseVersion,
+			ReleaseTag:   tags[c],
+			ChangedFiles: details[c].ChangedFiles,
+			MergeBase:    details[c].MergeBase,
 		}
-		if covered != nil && covered[c.Name] {
+		if declared[c.Dir] != "" {
 			cr.HasChangeset = true
 			result.CoveredCharts = append(result.CoveredCharts, cr)
 		} else {
@@ -127,6 +204,115 @@ func Run(opts Options) (*Result, error) {
 		}
 	}
 
+	// A chart's bump, inferred from its new version or declared in its
+	// changesets, must cover what its values and CRD changes require.
+	// Stale charts without a changeset already fail.
+	for _, c := range all {
+		// Compare with what the changed files were found against: the
+		// release tag, or the merge-base rather than the base branch tip.
+		s := sides{repoRoot: repoRoot, baseRef: details[c].Base, mode: opts.Mode}
+		if tags[c] == "" && details[c].MergeBase != "" {
+			s.baseRef = details[c].MergeBase
+		}
+		bump, baseVersion := inferred[c], details[c].BaseVersion
+		if bump == "" {
+			bump, baseVersion = declared[c.Dir], details[c].Version
+		}
+		findings, err := crdRule(s, idx.Label(c), c, details[c].ChangedFiles, bump, baseVersion)
+		if err != nil {
+			return nil, fmt.Errorf("comparing CRDs of %s: %w", idx.Label(c), err)
+		}
+		result.Findings = append(result.Findings, findings...)
+		if bump == "" {
+			continue
+		}
+		findings, err = valuesRule(s, idx.Label(c), c, details[c].ChangedFiles, bump, baseVersion)
+		if err != nil {
+			return nil, fmt.Errorf("comparing values of %s: %w", idx.Label(c), err)
+		}
+		result.Findings = append(result.Findings, findings...)
+	}
+
 	result.AllUpToDate = len(result.StaleCharts) == 0
 	return result, nil
 }
+
+// ChartRefs prepares charts for git.StaleCharts with the ignore rules of
+// each: its .helmignore (unless disabled), the global patterns, then the
+// patterns configured for the chart. Per-chart keys that match no chart
+// are skipped, like changeset entries for unknown charts.
+func ChartRefs(idx *chart.Index, charts []*chart.Chart, cfg config.Staleness) ([]git.ChartRef, error) {
+	perChart := make(map[*chart.Chart][]string)
+	for key, patterns := range cfg.Charts {
+		c, err := idx.Resolve(key)
+		if errors.Is(err, chart.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("staleness.charts: %w", err)
+		}
+		perChart[c] = append(perChart[c], patterns...)
+	}
+
+	refs := make([]git.ChartRef, len(charts))
+	for i, c := range charts {
+		rules := &ignore.Rules{}
+		if cfg.UseHelmignore() {
+			var err error
+			if rules, err = ignore.ParseFile(c.Dir); err != nil {
+				return nil, err
+			}
+		}
+		if err := rules.Add(cfg.Ignore...); err != nil {
+			return nil, err
+		}
+		if err := rules.Add(perChart[c]...); err != nil {
+			return nil, err
+		}
+		refs[i] = git.ChartRef{Dir: c.Dir, File: c.Path, Version: c.Version, Ignore: rules}
+	}
+	return refs, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// declaredBumps resolves every changeset entry to a chart and returns the
+// highest bump declared for each chart directory with a pending changeset.
+// A file that names an unknown chart covers nothing, since apply skips it
+// as a whole; an ambiguous chart name is an error.
+func declaredBumps(idx *chart.Index, files []*changeset.File) (map[string]string, error) {
+	declared := make(map[string]string)
+	for _, f := range files {
+		bumps := make(map[string]string)
+		complete := true
+		for _, e := range f.Entries {
+			c, err := idx.Resolve(e.Chart)
+			if errors.Is(err, chart.ErrNotFound) {
+				complete = false
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Path, err)
+			}
+			if chart.BumpRank(e.Bump) > chart.BumpRank(bumps[c.Dir]) {
+				bumps[c.Dir] = e.Bump
+			}
+		}
+		if !complete {
+			continue
+		}
+		for dir, bump := range bumps {
+			if chart.BumpRank(bump) > chart.BumpRank(declared[dir]) {
+				declared[dir] = bump
+			}
+		}
+	}
+	return declared, nil
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/jordan-simonovski/helmver/internal/changeset"
 	"github.com/jordan-simonovski/helmver/internal/check"
+	"github.com/jordan-simonovski/helmver/internal/config"
+	"github.com/jordan-simonovski/helmver/internal/git"
 )
 
 func gitRun(t *testing.T, dir string, args ...string) {
@@ -59,6 +61,151 @@ func TestRun_staleChart(t *testing.T) {
 	if result.AllUpToDate || len(result.StaleCharts) != 1 || result.StaleCharts[0].Name != "myapp" {
 		t.Fatalf("expected one stale chart, got %+v", result)
 	}
+	cr := result.StaleCharts[0]
+	if cr.BaseVersion != "1.0.0" || len(cr.ChangedFiles) != 1 || cr.ChangedFiles[0] != "values.yaml" || cr.MergeBase == "" {
+		t.Errorf("unexpected stale details: %+v", cr)
+	}
+}
+
+func TestRun_subchartChangeOnlyStalesSubchart(t *testing.T) {
+	dir := initRepo(t)
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: parent\nversion: 1.0.0\n")
+	mkFile(t, filepath.Join(dir, "charts", "redis", "Chart.yaml"), "apiVersion: v2\nname: redis\nversion: 0.1.0\n")
+	mkFile(t, filepath.Join(dir, "charts", "redis", "values.yaml"), "port: 6379\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	mkFile(t, filepath.Join(dir, "charts", "redis", "values.yaml"), "port: 6380\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "change redis")
+
+	result, err := check.Run(check.Options{Dir: dir, Base: "base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.StaleCharts) != 1 || result.StaleCharts[0].Name != "redis" {
+		t.Fatalf("expected only redis to be stale, got %+v", result.StaleCharts)
+	}
+}
+
+func TestRun_ignoredFiles(t *testing.T) {
+	dir := initRepo(t)
+	mkFile(t, filepath.Join(dir, "charts", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	mkFile(t, filepath.Join(dir, "charts", "api", ".helmignore"), "*.md\n")
+	mkFile(t, filepath.Join(dir, "charts", "web", "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	mkFile(t, filepath.Join(dir, "charts", "api", "README.md"), "docs\n")
+	mkFile(t, filepath.Join(dir, "charts", "api", "ci", "values.yaml"), "replicas: 1\n")
+	mkFile(t, filepath.Join(dir, "charts", "web", "tests", "svc_test.yaml"), "suite: svc\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "docs and tests")
+
+	opts := check.Options{Dir: dir, Base: "base", ChangesetRoot: dir}
+	result, err := check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// README.md is in api's .helmignore; ci/ and tests/ are not ignored yet.
+	if len(result.StaleCharts) != 2 || len(result.StaleCharts[0].ChangedFiles) != 1 {
+		t.Fatalf("expected api (ci/ only) and web to be stale, got %+v", result.StaleCharts)
+	}
+
+	opts.Config = &config.Config{Staleness: config.Staleness{
+		Ignore: []string{"ci/"},
+		Charts: map[string][]string{"web": {"tests/"}},
+	}}
+	result, err = check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.AllUpToDate {
+		t.Fatalf("expected no stale charts, got %+v", result.StaleCharts)
+	}
+
+	off := false
+	opts.Config = &config.Config{Staleness: config.Staleness{Helmignore: &off, Ignore: []string{"ci/", "tests/"}}}
+	result, err = check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.StaleCharts) != 1 || result.StaleCharts[0].Name != "api" {
+		t.Fatalf("expected api to be stale without .helmignore, got %+v", result.StaleCharts)
+	}
+}
+
+func TestRun_versionRules(t *testing.T) {
+	dir := initRepo(t)
+	for name, v := range map[string]string{"api": "1.4.0", "web": "1.2.0", "db": "0.3.0", "cache": "2.0.0"} {
+		mkFile(t, filepath.Join(dir, "charts", name, "Chart.yaml"), "apiVersion: v2\nname: "+name+"\nversion: "+v+"\n")
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	for name, v := range map[string]string{"api": "1.3.9", "web": "3.0.0", "db": "0.3", "cache": "2.1.0-rc.0"} {
+		mkFile(t, filepath.Join(dir, "charts", name, "Chart.yaml"), "apiVersion: v2\nname: "+name+"\nversion: "+v+"\n")
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "bump")
+
+	opts := check.Options{Dir: dir, Base: "base", ChangesetRoot: dir}
+	result, err := check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.AllUpToDate || !result.HasErrors() {
+		t.Fatalf("expected no stale charts but errors, got %+v", result)
+	}
+	rules := make(map[string]string)
+	for _, f := range result.Findings {
+		rules[f.Chart] = f.Rule + "/" + f.Severity
+	}
+	want := map[string]string{
+		"api": "version-downgrade/error",
+		"web": "non-adjacent-bump/warning",
+		"db":  "invalid-version/error",
+	}
+	if len(rules) != len(want) {
+		t.Errorf("findings = %v, want %v", rules, want)
+	}
+	for k, v := range want {
+		if rules[k] != v {
+			t.Errorf("%s: finding = %q, want %q", k, rules[k], v)
+		}
+	}
+	bumps := make(map[string]string)
+	for _, c := range result.BumpedCharts {
+		bumps[c.Name] = c.Bump
+	}
+	if len(bumps) != 4 || bumps["web"] != "major" || bumps["cache"] != "preminor" || bumps["api"] != "" {
+		t.Errorf("unexpected inferred bumps: %v", bumps)
+	}
+
+	opts.Config = &config.Config{Versions: config.Versions{NonAdjacent: "error"}}
+	result, err = check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range result.Findings {
+		if f.Chart == "web" && f.Severity != check.SeverityError {
+			t.Errorf("non-adjacent bump severity = %q, want error", f.Severity)
+		}
+	}
+
+	opts.Config = &config.Config{Versions: config.Versions{NonAdjacent: "off"}}
+	result, err = check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range result.Findings {
+		if f.Chart == "web" {
+			t.Errorf("unexpected finding with nonAdjacent off: %+v", f)
+		}
+	}
 }
 
 func TestRun_changesetCoverage(t *testing.T) {
@@ -89,6 +236,321 @@ func TestRun_changesetCoverage(t *testing.T) {
 	}
 }
 
+func TestRun_changesetWithUnknownChartCoversNothing(t *testing.T) {
+	dir := initRepo(t)
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
+	mkFile(t, filepath.Join(dir, "values.yaml"), "key: val\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	mkFile(t, filepath.Join(dir, "values.yaml"), "key: changed\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "change values")
+
+	// apply skips this file as a whole, so it must not cover myapp.
+	mkFile(t, filepath.Join(dir, ".helmver", "001.md"), "---\nmyapp: patch\nghost: patch\n---\n\nWill not bump\n")
+
+	result, err := check.Run(check.Options{
+		Dir:              dir,
+		Base:             "base",
+		RequireChangeset: true,
+		ChangesetRoot:    dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AllUpToDate || len(result.StaleCharts) != 1 || len(result.CoveredCharts) != 0 {
+		t.Fatalf("expected myapp to stay stale, got %+v", result)
+	}
+}
+
+func TestRun_valuesRule(t *testing.T) {
+	dir := initRepo(t)
+	for name, v := range map[string]string{"api": "1.2.0", "web": "1.2.0", "db": "0.3.0", "cache": "1.0.0"} {
+		mkFile(t, filepath.Join(dir, "charts", name, "Chart.yaml"), "apiVersion: v2\nname: "+name+"\nversion: "+v+"\n")
+		mkFile(t, filepath.Join(dir, "charts", name, "values.yaml"), "image:\n  tag: latest\nreplicas: 1\n")
+	}
+	mkFile(t, filepath.Join(dir, "charts", "cache", "values.schema.json"), `{"properties": {"replicas": {"type": "integer"}}}`)
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	// api: removed key with a patch bump; web: added key with a minor bump;
+	// db: removed key with a minor bump below 1.0.0; cache: newly required
+	// property with a patch changeset.
+	mkFile(t, filepath.Join(dir, "charts", "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.2.1\n")
+	mkFile(t, filepath.Join(dir, "charts", "api", "values.yaml"), "image: {}\nreplicas: 1\n")
+	mkFile(t, filepath.Join(dir, "charts", "web", "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.3.0\n")
+	mkFile(t, filepath.Join(dir, "charts", "web", "values.yaml"), "image:\n  tag: latest\nreplicas: 1\ningress: {}\n")
+	mkFile(t, filepath.Join(dir, "charts", "db", "Chart.yaml"), "apiVersion: v2\nname: db\nversion: 0.4.0\n")
+	mkFile(t, filepath.Join(dir, "charts", "db", "values.yaml"), "replicas: 1\n")
+	mkFile(t, filepath.Join(dir, "charts", "cache", "values.schema.json"), `{"required": ["replicas"], "properties": {"replicas": {"type": "integer"}}}`)
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "change values")
+	mkFile(t, filepath.Join(dir, ".helmver", "001.md"), "---\ncache: patch\n---\n\nRequire replicas\n")
+
+	result, err := check.Run(check.Options{Dir: dir, Base: "base", RequireChangeset: true, ChangesetRoot: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range result.Findings {
+		if f.Rule == "values-bump" {
+			got[f.Chart] = f.Message
+		}
+	}
+	want := map[string]string{
+		"api":   "patch bump, but the values changes require a major bump: removed key image.tag",
+		"cache": "patch bump, but the values changes require a major bump: made schema property replicas required",
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: finding = %q, want %q", k, got[k], v)
+		}
+	}
+	if !result.HasErrors() {
+		t.Error("expected the values findings to be errors")
+	}
+}
+
+func TestRun_valuesRuleDivergedBase(t *testing.T) {
+	dir := initRepo(t)
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.2.0\n")
+	mkFile(t, filepath.Join(dir, "values.yaml"), "image:\n  tag: latest\nreplicas: 1\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+
+	// The base branch moves on and adds a key the feature branch never saw.
+	gitRun(t, dir, "checkout", "-q", "-b", "base")
+	mkFile(t, filepath.Join(dir, "values.yaml"), "image:\n  tag: latest\nreplicas: 1\ndebug: false\n")
+	gitRun(t, dir, "commit", "-qam", "add debug")
+	gitRun(t, dir, "checkout", "-q", "-")
+
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.3.0\n")
+	mkFile(t, filepath.Join(dir, "values.yaml"), "image:\n  tag: latest\nreplicas: 1\ningress: {}\n")
+	gitRun(t, dir, "commit", "-qam", "add ingress")
+
+	valuesFindings := func(mode git.Mode) []check.Finding {
+		t.Helper()
+		result, err := check.Run(check.Options{Dir: dir, Base: "base", ChangesetRoot: dir, Mode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []check.Finding
+		for _, f := range result.Findings {
+			if f.Rule == "values-bump" {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+
+	// debug is not a removal: it was added on the base branch after the
+	// merge-base.
+	if got := valuesFindings(git.Committed); len(got) != 0 {
+		t.Errorf("expected no values findings against the merge-base, got %v", got)
+	}
+
+	// An uncommitted removal is only seen when checking the working tree.
+	mkFile(t, filepath.Join(dir, "values.yaml"), "image: {}\nreplicas: 1\ningress: {}\n")
+	if got := valuesFindings(git.Committed); len(got) != 0 {
+		t.Errorf("committed check should not read the working tree, got %v", got)
+	}
+	if got := valuesFindings(git.WorkingTree); len(got) != 1 || !strings.Contains(got[0].Message, "removed key image.tag") {
+		t.Errorf("expected a working-tree finding for image.tag, got %v", got)
+	}
+}
+
+func TestRun_crdRule(t *testing.T) {
+	crdYAML := func(props string) string {
+		return "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n" +
+			"spec:\n  versions:\n    - name: v1\n      served: true\n      schema:\n        openAPIV3Schema:\n" +
+			"          type: object\n          properties:\n" + props
+	}
+	dir := initRepo(t)
+	for _, name := range []string{"operator", "agent"} {
+		mkFile(t, filepath.Join(dir, name, "Chart.yaml"), "apiVersion: v2\nname: "+name+"\nversion: 1.0.0\n")
+		mkFile(t, filepath.Join(dir, name, "crds", "widgets.yaml"), crdYAML("            size: {type: integer}\n"))
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	// operator removes a field with a minor bump; agent adds one with a
+	// patch bump.
+	mkFile(t, filepath.Join(dir, "operator", "Chart.yaml"), "apiVersion: v2\nname: operator\nversion: 1.1.0\n")
+	mkFile(t, filepath.Join(dir, "operator", "crds", "widgets.yaml"), crdYAML("            color: {type: string}\n"))
+	mkFile(t, filepath.Join(dir, "agent", "Chart.yaml"), "apiVersion: v2\nname: agent\nversion: 1.0.1\n")
+	mkFile(t, filepath.Join(dir, "agent", "crds", "widgets.yaml"), crdYAML("            size: {type: integer}\n            color: {type: string}\n"))
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "change CRDs")
+
+	result, err := check.Run(check.Options{Dir: dir, Base: "base", ChangesetRoot: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range result.Findings {
+		got[f.Chart+"/"+f.Rule+"/"+f.Severity] = f.Message
+	}
+	want := map[string]string{
+		"operator/crd-bump/error":     "minor bump, but the CRD changes require a major bump: widgets.example.com v1: removed field size",
+		"operator/crd-change/warning": "helm upgrade does not apply CRD changes: widgets.example.com v1: added field color",
+		"agent/crd-change/warning":    "helm upgrade does not apply CRD changes: widgets.example.com v1: added field color",
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: finding = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRun_crdRuleDivergedBase(t *testing.T) {
+	crdYAML := func(props string) string {
+		return "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n" +
+			"spec:\n  versions:\n    - name: v1\n      served: true\n      schema:\n        openAPIV3Schema:\n" +
+			"          type: object\n          properties:\n" + props
+	}
+	dir := initRepo(t)
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: operator\nversion: 1.0.0\n")
+	mkFile(t, filepath.Join(dir, "crds", "widgets.yaml"), crdYAML("            size: {type: integer}\n"))
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+
+	// The base branch adds a field after the feature branch forked.
+	gitRun(t, dir, "checkout", "-q", "-b", "base")
+	mkFile(t, filepath.Join(dir, "crds", "widgets.yaml"), crdYAML("            size: {type: integer}\n            color: {type: string}\n"))
+	gitRun(t, dir, "commit", "-qam", "add color")
+	gitRun(t, dir, "checkout", "-q", "-")
+
+	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: operator\nversion: 1.0.1\n")
+	mkFile(t, filepath.Join(dir, "crds", "widgets.yaml"), crdYAML("            size: {type: integer}\n            weight: {type: integer}\n"))
+	gitRun(t, dir, "commit", "-qam", "add weight")
+
+	result, err := check.Run(check.Options{Dir: dir, Base: "base", ChangesetRoot: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range result.Findings {
+		got[f.Chart+"/"+f.Rule+"/"+f.Severity] = f.Message
+	}
+	// color was never removed by this branch.
+	want := map[string]string{
+		"operator/crd-change/warning": "helm upgrade does not apply CRD changes: widgets.example.com v1: added field weight",
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: finding = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRun_releaseTags(t *testing.T) {
+	dir := initRepo(t)
+	for _, name := range []string{"api", "web", "db"} {
+		mkFile(t, filepath.Join(dir, name, "Chart.yaml"), "apiVersion: v2\nname: "+name+"\nversion: 1.0.0\n")
+		mkFile(t, filepath.Join(dir, name, "values.yaml"), "key: val\n")
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "tag", "api-1.0.0")
+	gitRun(t, dir, "tag", "web-1.0.0")
+
+	// api is released again, then changes; web changes after its release;
+	// db was never released.
+	mkFile(t, filepath.Join(dir, "api", "Chart.yaml"), "apiVersion: v2\nname: api\nversion: 1.1.0\n")
+	gitRun(t, dir, "commit", "-am", "release api")
+	gitRun(t, dir, "tag", "api-1.1.0")
+	gitRun(t, dir, "tag", "api-notsemver")
+	for _, name := range []string{"api", "web", "db"} {
+		mkFile(t, filepath.Join(dir, name, "values.yaml"), "key: changed\n")
+	}
+	gitRun(t, dir, "commit", "-am", "change values")
+
+	opts := check.Options{Dir: dir, Base: "missing", ChangesetRoot: dir, TagPattern: "{name}-{version}"}
+	if _, err := check.Run(opts); err == nil || !strings.Contains(err.Error(), `base ref "missing" not found`) {
+		t.Fatalf("expected db to need the base ref, got %v", err)
+	}
+
+	gitRun(t, dir, "branch", "main")
+	opts.Base = "main"
+	result, err := check.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, c := range result.StaleCharts {
+		got[c.Name] = c.ReleaseTag + " " + c.BaseVersion
+	}
+	want := map[string]string{"api": "api-1.1.0 1.1.0", "web": "web-1.0.0 1.0.0"}
+	if len(got) != len(want) || got["api"] != want["api"] || got["web"] != want["web"] {
+		t.Errorf("stale charts = %v, want %v", got, want)
+	}
+
+	opts.TagPattern = "{name}"
+	if _, err := check.Run(opts); err == nil {
+		t.Error("expected an error for a pattern without {version}")
+	}
+}
+
+func TestRun_changesetCoverageByPath(t *testing.T) {
+	dir := initRepo(t)
+	for _, env := range []string{"prod", "stage"} {
+		mkFile(t, filepath.Join(dir, env, "Chart.yaml"), "apiVersion: v2\nname: app\nversion: 1.0.0\n")
+		mkFile(t, filepath.Join(dir, env, "values.yaml"), "key: val\n")
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "init")
+	gitRun(t, dir, "branch", "base")
+
+	for _, env := range []string{"prod", "stage"} {
+		mkFile(t, filepath.Join(dir, env, "values.yaml"), "key: changed\n")
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "change values")
+
+	mkFile(t, filepath.Join(dir, ".helmver", "001.md"), "---\n\"prod/\": patch\n---\n\nprod only\n")
+
+	result, err := check.Run(check.Options{
+		Dir:              dir,
+		Base:             "base",
+		RequireChangeset: true,
+		ChangesetRoot:    dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.CoveredCharts) != 1 || result.CoveredCharts[0].ID != "prod" {
+		t.Fatalf("expected prod covered, got %+v", result.CoveredCharts)
+	}
+	if len(result.StaleCharts) != 1 || result.StaleCharts[0].ID != "stage" {
+		t.Fatalf("expected stage stale, got %+v", result.StaleCharts)
+	}
+
+	// A bare name that matches both charts is rejected.
+	mkFile(t, filepath.Join(dir, ".helmver", "002.md"), "---\n\"app\": patch\n---\n\nboth?\n")
+	_, err = check.Run(check.Options{
+		Dir:              dir,
+		Base:             "base",
+		RequireChangeset: true,
+		ChangesetRoot:    dir,
+	})
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected ambiguous name error, got %v", err)
+	}
+}
+
 func TestRun_missingBaseRef(t *testing.T) {
 	dir := initRepo(t)
 	mkFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: myapp\nversion: 1.0.0\n")
@@ -0,0 +1,110 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/crd"
+	"github.com/jordan-simonovski/helmver/internal/git"
+)
+
+// crdRule compares a chart's crds/ directory with baseRef when any file in
+// it changed. Helm never upgrades CRDs, so a breaking change needs a major
+// bump (see bumpSatisfies); bump is the declared or inferred bump, or ""
+// for a stale chart without a changeset, which already fails. Other CRD
+// changes are warnings.
+func crdRule(s sides, label string, c *chart.Chart, changed []string, bump, baseVersion string) ([]Finding, error) {
+	relDir, err := git.RelPath(s.repoRoot, c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	touched := false
+	for _, f := range changed {
+		if crd.IsFile(git.ChartRelative(relDir, f)) {
+			touched = true
+			break
+		}
+	}
+	if !touched {
+		return nil, nil
+	}
+
+	cannotCompare := func(err error) []Finding {
+		return []Finding{{Chart: label, Rule: "crd-change", Severity: SeverityWarning, Message: fmt.Sprintf("cannot compare CRDs: %s", err)}}
+	}
+	base, err := loadCRDs(relDir, s.baseFiles, s.base)
+	if err != nil {
+		return cannotCompare(err), nil
+	}
+	head, err := loadCRDs(relDir, s.headFiles, s.head)
+	if err != nil {
+		return cannotCompare(err), nil
+	}
+	return crdFindings(label, crd.Diff(base, head), bump, baseVersion), nil
+}
+
+// crdFindings turns CRD changes into findings: an error for breaking
+// changes that bump does not cover, and a warning for the others.
+func crdFindings(label string, changes []crd.Change, bump, baseVersion string) []Finding {
+	var breaking, other []string
+	for _, ch := range changes {
+		if ch.Breaking {
+			breaking = append(breaking, ch.String())
+		} else {
+			other = append(other, ch.String())
+		}
+	}
+
+	var findings []Finding
+	if len(breaking) > 0 && bump != "" && !bumpSatisfies(bump, "major", baseVersion) {
+		findings = append(findings, Finding{
+			Chart:    label,
+			Rule:     "crd-bump",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s bump, but the CRD changes require a major bump: %s", bump, listChanges(breaking)),
+		})
+	}
+	if len(other) > 0 {
+		findings = append(findings, Finding{
+			Chart:    label,
+			Rule:     "crd-change",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("helm upgrade does not apply CRD changes: %s", listChanges(other)),
+		})
+	}
+	return findings
+}
+
+// loadCRDs reads the CRDs in a chart's crds/ directory on one side of the
+// comparison.
+func loadCRDs(relDir string, list func(string) ([]string, error), read func(string) ([]byte, error)) (crd.Set, error) {
+	files, err := list(path.Join(relDir, crd.Dir))
+	if err != nil {
+		return nil, err
+	}
+	set := crd.Set{}
+	for _, f := range files {
+		if !crd.IsFile(git.ChartRelative(relDir, f)) {
+			continue
+		}
+		data, err := read(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := set.Add(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	return set, nil
+}
+
+// listChanges joins change descriptions for a finding, naming at most
+// maxListedChanges of them.
+func listChanges(changes []string) string {
+	if n := len(changes) - maxListedChanges; n > 0 {
+		changes = append(changes[:maxListedChanges:maxListedChanges], fmt.Sprintf("and %d more", n))
+	}
+	return strings.Join(changes, ", ")
+}
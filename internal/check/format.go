@@ -3,7 +3,10 @@ package check
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
+
+	"github.com/jordan-simonovski/helmver/internal/changeset"
 )
 
 // Format renders a check result as json or markdown.
@@ -25,12 +28,18 @@ func formatJSON(result *Result, commitSHA string) (string, error) {
 		StaleCharts   []ChartResult `json:"staleCharts"`
 		CoveredCharts []ChartResult `json:"coveredCharts"`
 		Changesets    int           `json:"changesetCount"`
+		Pending       []pendingJSON `json:"changesets"`
+		BumpedCharts  []ChartResult `json:"bumpedCharts"`
+		Findings      []Finding     `json:"findings"`
 	}{
 		CommitSHA:     commitSHA,
 		AllUpToDate:   result.AllUpToDate,
 		StaleCharts:   result.StaleCharts,
 		CoveredCharts: result.CoveredCharts,
 		Changesets:    len(result.Changesets),
+		Pending:       pendingChangesets(result.Changesets),
+		BumpedCharts:  nonNil(result.BumpedCharts),
+		Findings:      nonNil(result.Findings),
 	}
 	b, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
@@ -39,10 +48,56 @@ func formatJSON(result *Result, commitSHA string) (string, error) {
 	return string(b), nil
 }
 
+// nonNil keeps empty lists as [] rather than null in JSON output.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+type pendingJSON struct {
+	File    string            `json:"file"`
+	Charts  map[string]string `json:"charts"`
+	Type    string            `json:"type,omitempty"`
+	Issues  []string          `json:"issues,omitempty"`
+	PR      string            `json:"pr,omitempty"`
+	Authors []string          `json:"authors,omitempty"`
+}
+
+func pendingChangesets(files []*changeset.File) []pendingJSON {
+	out := make([]pendingJSON, 0, len(files))
+	for _, f := range files {
+		p := pendingJSON{
+			File:    filepath.Base(f.Path),
+			Charts:  make(map[string]string, len(f.Entries)),
+			Type:    f.Type,
+			Issues:  f.Issues,
+			PR:      f.PR,
+			Authors: f.Authors,
+		}
+		for _, e := range f.Entries {
+			p.Charts[e.Chart] = e.Bump
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
 func formatMarkdown(result *Result, commitSHA string) string {
 	var b strings.Builder
 
 	switch {
+	case result.HasErrors():
+		b.WriteString("### ❌ Chart versions are invalid\n\n")
+		writeCommitLine(&b, commitSHA)
+		b.WriteString("The following chart versions break the version rules:\n\n")
+		writeFindingsTable(&b, result.Findings)
+		if len(result.StaleCharts) > 0 {
+			b.WriteString("\nThese charts also have file changes without a version bump or pending changeset:\n\n")
+			writeStaleTable(&b, result.StaleCharts)
+			writeChangedFiles(&b, result.StaleCharts)
+		}
 	case result.AllUpToDate && len(result.CoveredCharts) > 0:
 		b.WriteString("### ✅ Changeset detected\n\n")
 		writeCommitLine(&b, commitSHA)
@@ -57,6 +112,7 @@ func formatMarkdown(result *Result, commitSHA string) string {
 		writeCommitLine(&b, commitSHA)
 		b.WriteString("The following charts have file changes without a version bump or pending changeset:\n\n")
 		writeStaleTable(&b, result.StaleCharts)
+		writeChangedFiles(&b, result.StaleCharts)
 		b.WriteString("\nRun `helmver changeset --write` locally and commit the `.helmver/` files, or bump `version` in `Chart.yaml` directly.\n")
 		if len(result.CoveredCharts) > 0 {
 			b.WriteString("\nThese stale charts already have a pending changeset:\n\n")
@@ -64,6 +120,12 @@ func formatMarkdown(result *Result, commitSHA string) string {
 		}
 	}
 
+	if len(result.Findings) > 0 && !result.HasErrors() {
+		b.WriteString("\n#### Warnings\n\n")
+		writeFindingsTable(&b, result.Findings)
+	}
+	writeBumpTable(&b, result.BumpedCharts)
+
 	b.WriteString("\n\n[Learn about helmver changesets](https://github.com/jordan-simonovski/helmver#changeset-files)")
 	return b.String()
 }
@@ -82,6 +144,69 @@ func writeStaleTable(b *strings.Builder, charts []ChartResult) {
 	}
 }
 
+// maxListedFiles caps the changed files listed per chart so large changes
+// stay within PR comment size limits.
+const maxListedFiles = 50
+
+// writeChangedFiles lists, per chart, the files that made it stale in a
+// collapsed block.
+func writeChangedFiles(b *strings.Builder, charts []ChartResult) {
+	for _, c := range charts {
+		if len(c.ChangedFiles) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\n<details>\n<summary>%s: %d changed file(s)</summary>\n\n", c.Name, len(c.ChangedFiles))
+		if c.ReleaseTag != "" {
+			fmt.Fprintf(b, "Compared with release tag `%s`", c.ReleaseTag)
+			if c.BaseVersion != "" {
+				fmt.Fprintf(b, ", where `version` is already %s", c.BaseVersion)
+			}
+			b.WriteString(".\n\n")
+		} else if c.MergeBase != "" {
+			fmt.Fprintf(b, "Compared with merge-base `%.12s`", c.MergeBase)
+			if c.BaseVersion != "" {
+				fmt.Fprintf(b, ", where `version` is already %s", c.BaseVersion)
+			}
+			b.WriteString(".\n\n")
+		}
+		for i, f := range c.ChangedFiles {
+			if i == maxListedFiles {
+				fmt.Fprintf(b, "- … and %d more\n", len(c.ChangedFiles)-maxListedFiles)
+				break
+			}
+			fmt.Fprintf(b, "- `%s`\n", f)
+		}
+		b.WriteString("\n</details>\n")
+	}
+}
+
+func writeFindingsTable(b *strings.Builder, findings []Finding) {
+	b.WriteString("| Chart | Rule | Severity | Message |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, f := range findings {
+		fmt.Fprintf(b, "| %s | `%s` | %s | %s |\n", f.Chart, f.Rule, f.Severity, f.Message)
+	}
+}
+
+// writeBumpTable lists the version changes against the base ref with the
+// bump kind inferred for each.
+func writeBumpTable(b *strings.Builder, charts []ChartResult) {
+	if len(charts) == 0 {
+		return
+	}
+	b.WriteString("\n<details>\n<summary>Version bumps</summary>\n\n")
+	b.WriteString("| Chart | Base | Version | Bump |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, c := range charts {
+		bump := c.Bump
+		if bump == "" {
+			bump = "-"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", c.Name, c.BaseVersion, c.Version, bump)
+	}
+	b.WriteString("\n</details>\n")
+}
+
 func writeCoveredTable(b *strings.Builder, charts []ChartResult) {
 	b.WriteString("| Chart | Version | Directory |\n")
 	b.WriteString("| --- | --- | --- |\n")
@@ -96,35 +221,40 @@ func writeChangesetTable(b *strings.Builder, result *Result) {
 	}
 
 	b.WriteString("<details>\n<summary>Pending changesets</summary>\n\n")
-	b.WriteString("| Chart | Bump |\n")
-	b.WriteString("| --- | --- |\n")
+	b.WriteString("| Chart | Bump | Type |\n")
+	b.WriteString("| --- | --- | --- |\n")
 	var order []string
-	seen := make(map[string]string)
+	seen := make(map[string]bool)
 	for _, f := range result.Changesets {
 		for _, e := range f.Entries {
-			if _, ok := seen[e.Chart]; !ok {
+			if !seen[e.Chart] {
 				order = append(order, e.Chart)
-				seen[e.Chart] = e.Bump
-			} else if bumpRank(e.Bump) > bumpRank(seen[e.Chart]) {
-				seen[e.Chart] = e.Bump
+				seen[e.Chart] = true
 			}
 		}
 	}
-	for _, chart := range order {
-		fmt.Fprintf(b, "| %s | %s |\n", chart, seen[chart])
+	// A conflicting appVersion does not change the bump; apply reports it.
+	resolved, _ := changeset.Aggregate(result.Changesets)
+	for _, name := range order {
+		r := resolved[name]
+		fmt.Fprintf(b, "| %s | %s | %s |\n", name, r.Bump, changeTypes(r.Changes))
 	}
 	b.WriteString("\n</details>\n")
 }
 
-func bumpRank(bump string) int {
-	switch bump {
-	case "major":
-		return 3
-	case "minor":
-		return 2
-	case "patch":
-		return 1
-	default:
-		return 0
+// changeTypes lists the distinct change types of a chart's pending
+// changesets, or "-" if none is set.
+func changeTypes(changes []changeset.Change) string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, c := range changes {
+		if c.Type != "" && !seen[c.Type] {
+			seen[c.Type] = true
+			types = append(types, c.Type)
+		}
+	}
+	if len(types) == 0 {
+		return "-"
 	}
+	return strings.Join(types, ", ")
 }
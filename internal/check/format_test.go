@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jordan-simonovski/helmver/internal/changeset"
 	"github.com/jordan-simonovski/helmver/internal/check"
 )
 
@@ -50,6 +51,110 @@ func TestFormatMarkdown_withChangeset(t *testing.T) {
 	}
 }
 
+func TestFormatMarkdown_changesetTypes(t *testing.T) {
+	result := &check.Result{
+		AllUpToDate: true,
+		CoveredCharts: []check.ChartResult{
+			{Name: "api", Version: "1.2.3", Dir: "/charts/api", HasChangeset: true},
+		},
+		Changesets: []*changeset.File{
+			{Entries: []changeset.Entry{{Chart: "api", Bump: "patch"}}, Metadata: changeset.Metadata{Type: "fixed"}},
+			{Entries: []changeset.Entry{{Chart: "api", Bump: "minor"}}, Metadata: changeset.Metadata{Type: "added"}},
+			{Entries: []changeset.Entry{{Chart: "web", Bump: "patch"}}},
+		},
+	}
+	out, err := check.Format(result, "markdown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out, "| Chart | Bump | Type |", "| api | minor | fixed, added |", "| web | patch | - |") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, err = check.Format(result, "json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out, `"changesets": [`, `"type": "fixed"`, `"api": "minor"`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestFormatMarkdown_changedFiles(t *testing.T) {
+	result := &check.Result{
+		StaleCharts: []check.ChartResult{{
+			Name:         "api",
+			Version:      "1.2.3",
+			Dir:          "/charts/api",
+			BaseVersion:  "1.2.3",
+			ChangedFiles: []string{"charts/api/values.yaml", "charts/api/templates/svc.yaml"},
+			MergeBase:    "0123456789abcdef0123456789abcdef01234567",
+		}},
+	}
+	out, err := check.Format(result, "markdown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<details>\n<summary>api: 2 changed file(s)</summary>\n\n" +
+		"Compared with merge-base `0123456789ab`, where `version` is already 1.2.3.\n\n" +
+		"- `charts/api/values.yaml`\n- `charts/api/templates/svc.yaml`\n\n</details>\n"
+	if !strings.Contains(out, want) {
+		t.Fatalf("missing changed files block in:\n%s", out)
+	}
+
+	out, err = check.Format(result, "json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out, `"baseVersion": "1.2.3"`, `"changedFiles": [`, `"charts/api/values.yaml"`, `"mergeBase": "0123456789abcdef`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestFormatMarkdown_findings(t *testing.T) {
+	result := &check.Result{
+		AllUpToDate: true,
+		BumpedCharts: []check.ChartResult{
+			{Name: "api", Version: "1.3.9", BaseVersion: "1.4.0"},
+			{Name: "web", Version: "3.0.0", BaseVersion: "1.2.0", Bump: "major"},
+		},
+		Findings: []check.Finding{
+			{Chart: "api", Rule: "version-downgrade", Severity: check.SeverityError, Message: "version lowered from 1.4.0 to 1.3.9"},
+			{Chart: "web", Rule: "non-adjacent-bump", Severity: check.SeverityWarning, Message: "major bump from 1.2.0 to 3.0.0 skips versions"},
+		},
+	}
+	out, err := check.Format(result, "markdown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out,
+		"### ❌ Chart versions are invalid",
+		"| api | `version-downgrade` | error | version lowered from 1.4.0 to 1.3.9 |",
+		"| web | `non-adjacent-bump` | warning |",
+		"| api | 1.4.0 | 1.3.9 | - |",
+		"| web | 1.2.0 | 3.0.0 | major |",
+	) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	result.Findings = result.Findings[1:]
+	out, err = check.Format(result, "markdown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out, "### ✅ All charts up to date", "#### Warnings", "skips versions") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, err = check.Format(result, "json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(out, `"bumpedCharts": [`, `"bump": "major"`, `"findings": [`, `"rule": "non-adjacent-bump"`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
 func TestFormatJSON(t *testing.T) {
 	result := &check.Result{
 		AllUpToDate: false,
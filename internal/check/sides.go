@@ -0,0 +1,77 @@
+package check
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jordan-simonovski/helmver/internal/git"
+)
+
+// sides reads the two versions of a file that the rules compare: the one
+// at the base ref, which is the merge-base or release tag the chart's
+// changed files were found against, and the current one from HEAD, the
+// index or the working tree, following the mode. Paths are relative to
+// repoRoot, slash-separated.
+type sides struct {
+	repoRoot string
+	baseRef  string
+	mode     git.Mode
+}
+
+// base returns relFile at the base ref, or nil if it does not exist there.
+func (s sides) base(relFile string) ([]byte, error) {
+	data, _, err := git.ShowFile(s.repoRoot, s.baseRef, relFile)
+	return data, err
+}
+
+// head returns the current relFile, or nil if it does not exist.
+func (s sides) head(relFile string) ([]byte, error) {
+	if s.mode != git.WorkingTree {
+		data, _, err := git.ShowFile(s.repoRoot, s.headRef(), relFile)
+		return data, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.repoRoot, filepath.FromSlash(relFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// headRef is the ref head reads from outside WorkingTree mode: HEAD, or
+// the index ("") when staged.
+func (s sides) headRef() string {
+	if s.mode == git.Staged {
+		return ""
+	}
+	return "HEAD"
+}
+
+// baseFiles lists the files under relDir at the base ref.
+func (s sides) baseFiles(relDir string) ([]string, error) {
+	return git.ListFiles(s.repoRoot, s.baseRef, relDir)
+}
+
+// headFiles lists the current files under relDir.
+func (s sides) headFiles(relDir string) ([]string, error) {
+	if s.mode != git.WorkingTree {
+		return git.ListFiles(s.repoRoot, s.headRef(), relDir)
+	}
+	var files []string
+	err := filepath.WalkDir(filepath.Join(s.repoRoot, filepath.FromSlash(relDir)), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.repoRoot, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/git"
+)
+
+// ReleaseTags sets the Base of each chart ref to the chart's most recent
+// release tag: of the tags reachable from HEAD that match pattern, the one
+// with the highest version. Tags whose version is not semver are skipped,
+// and charts without a release tag keep the base ref. It reports whether
+// any chart kept it.
+func ReleaseTags(repoRoot string, pattern git.TagPattern, charts []*chart.Chart, refs []git.ChartRef) (bool, error) {
+	tags, err := git.MergedTags(repoRoot)
+	if err != nil {
+		return false, err
+	}
+	untagged := false
+	for i, c := range charts {
+		var best chart.Version
+		for _, tag := range tags {
+			s, ok := pattern.Match(tag, c.Name)
+			if !ok {
+				continue
+			}
+			v, err := chart.ParseVersion(s)
+			if err != nil {
+				continue
+			}
+			if refs[i].Base == "" || v.Compare(best) > 0 {
+				refs[i].Base, best = tag, v
+			}
+		}
+		if refs[i].Base == "" {
+			untagged = true
+		}
+	}
+	return untagged, nil
+}
@@ -0,0 +1,106 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/git"
+	"github.com/jordan-simonovski/helmver/internal/values"
+)
+
+// maxListedChanges caps the values changes named in one finding.
+const maxListedChanges = 5
+
+// valuesRule checks that a chart's bump is at least what the changes to
+// its values.yaml and values.schema.json since baseRef require: major for
+// removed keys, type changes and newly required schema properties, minor
+// for added keys. bump is the declared or inferred bump and baseVersion
+// the version it applies to. changed lists the chart's changed files
+// relative to the repository root; values files that did not change are
+// not read.
+func valuesRule(s sides, label string, c *chart.Chart, changed []string, bump, baseVersion string) ([]Finding, error) {
+	relDir, err := git.RelPath(s.repoRoot, c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	isChanged := make(map[string]bool, len(changed))
+	for _, f := range changed {
+		isChanged[f] = true
+	}
+
+	var findings []Finding
+	var changes []values.Change
+	for _, file := range []struct {
+		name string
+		diff func(base, head []byte) ([]values.Change, error)
+	}{
+		{values.File, values.Diff},
+		{values.SchemaFile, values.DiffSchema},
+	} {
+		rel := path.Join(relDir, file.name)
+		if !isChanged[rel] {
+			continue
+		}
+		base, err := s.base(rel)
+		if err != nil {
+			return nil, err
+		}
+		head, err := s.head(rel)
+		if err != nil {
+			return nil, err
+		}
+		cs, err := file.diff(base, head)
+		if err != nil {
+			findings = append(findings, Finding{Chart: label, Rule: "values-bump", Severity: SeverityWarning, Message: fmt.Sprintf("cannot compare %s: %s", file.name, err)})
+			continue
+		}
+		changes = append(changes, cs...)
+	}
+
+	required := values.Bump(changes)
+	if required == "" || bumpSatisfies(bump, required, baseVersion) {
+		return findings, nil
+	}
+	var listed []string
+	for _, ch := range changes {
+		if required == "minor" || ch.Breaking() {
+			listed = append(listed, ch.String())
+		}
+	}
+	return append(findings, Finding{
+		Chart:    label,
+		Rule:     "values-bump",
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("%s bump, but the values changes require a %s bump: %s", bump, required, listChanges(listed)),
+	}), nil
+}
+
+// bumpSatisfies reports whether bump from baseVersion is at least the
+// required "major" or "minor" bump. Following semver, a minor bump is
+// enough for breaking changes below 1.0.0, and pre-releases make no
+// promises, so any bump from one will do.
+func bumpSatisfies(bump, required, baseVersion string) bool {
+	if v, err := chart.ParseVersion(baseVersion); err == nil {
+		if v.IsPrerelease() {
+			return true
+		}
+		if v.Major == 0 && required == "major" {
+			required = "minor"
+		}
+	}
+	return bumpLevel(bump) >= bumpLevel(required)
+}
+
+// bumpLevel ranks a bump by the version component it changes: 3 for
+// major and premajor, 2 for minor and preminor, 1 for the rest.
+func bumpLevel(bump string) int {
+	switch strings.TrimPrefix(bump, "pre") {
+	case "major":
+		return 3
+	case "minor":
+		return 2
+	}
+	return 1
+}
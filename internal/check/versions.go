@@ -0,0 +1,77 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/jordan-simonovski/helmver/internal/chart"
+	"github.com/jordan-simonovski/helmver/internal/config"
+)
+
+// Finding severities. Errors fail helmver check; warnings are reported
+// only.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is a problem with a chart that helmver check reports besides
+// staleness.
+type Finding struct {
+	Chart    string `json:"chart"`    // chart name, or its path when ambiguous
+	Rule     string `json:"rule"`     // e.g. "version-downgrade"
+	Severity string `json:"severity"` // SeverityError or SeverityWarning
+	Message  string `json:"message"`
+}
+
+// HasErrors reports whether any finding is an error.
+func (r *Result) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// versionRules compares a chart's version at HEAD with its version at the
+// base ref, which differ. It returns the bump it infers ("" when either
+// version is not semver) and any findings:
+//
+//   - invalid-version: the new version is not valid semver (error)
+//   - version-downgrade: the new version is lower than the base (error)
+//   - version-equal: the version string changed but its precedence did
+//     not, e.g. only build metadata (warning)
+//   - non-adjacent-bump: the bump skips versions, e.g. 1.2.0 -> 3.0.0
+//     (warning, or as configured)
+func versionRules(label, base, head string, cfg config.Versions) (string, []Finding) {
+	finding := func(rule, severity, format string, args ...any) []Finding {
+		return []Finding{{Chart: label, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	next, err := chart.ParseVersion(head)
+	if err != nil {
+		return "", finding("invalid-version", SeverityError, "%s", err)
+	}
+	prev, err := chart.ParseVersion(base)
+	if err != nil {
+		// Nothing to compare against; the new version is valid.
+		return "", nil
+	}
+
+	switch next.Compare(prev) {
+	case -1:
+		return "", finding("version-downgrade", SeverityError, "version lowered from %s to %s", base, head)
+	case 0:
+		return "", finding("version-equal", SeverityWarning, "version changed from %s to %s without changing its precedence", base, head)
+	}
+
+	bump, adjacent := chart.InferBump(prev, next)
+	if adjacent || cfg.NonAdjacent == "off" {
+		return bump, nil
+	}
+	severity := SeverityWarning
+	if cfg.NonAdjacent == "error" {
+		severity = SeverityError
+	}
+	return bump, finding("non-adjacent-bump", severity, "%s bump from %s to %s skips versions", bump, base, head)
+}
@@ -0,0 +1,104 @@
+// Package clock supplies the release date written to changelogs, so that
+// the same apply produces the same output on every machine.
+package clock
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded so date.timezone works in images without zoneinfo, such as
+	// the alpine-based Docker image.
+	_ "time/tzdata"
+)
+
+// DefaultLayout is the date layout of changelog headings.
+const DefaultLayout = "2006-01-02"
+
+// SourceDateEpochEnv names the environment variable holding a Unix
+// timestamp to use instead of the current time; see
+// https://reproducible-builds.org/specs/source-date-epoch/.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// Clock is the source of release dates.
+type Clock struct {
+	now    func() time.Time
+	loc    *time.Location
+	layout string
+}
+
+// System uses the current time in the local timezone and DefaultLayout.
+var System = &Clock{now: time.Now, loc: time.Local, layout: DefaultLayout}
+
+// New returns a clock for the given release date, timezone and layout.
+//
+// date may be a calendar date ("2025-03-01"), taken as midnight in the
+// clock's timezone, or an RFC 3339 timestamp. When date is empty,
+// SOURCE_DATE_EPOCH is used if set, and the current time otherwise.
+//
+// timezone is an IANA name such as "UTC" or "Europe/Berlin". When empty it
+// is UTC if SOURCE_DATE_EPOCH is set, as the specification requires, and
+// the local timezone otherwise. An empty layout selects DefaultLayout.
+func New(date, timezone, layout string) (*Clock, error) {
+	epoch := os.Getenv(SourceDateEpochEnv)
+
+	loc := time.Local
+	switch {
+	case timezone != "":
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q: %w", timezone, err)
+		}
+		loc = l
+	case epoch != "":
+		loc = time.UTC
+	}
+	if layout == "" {
+		layout = DefaultLayout
+	}
+
+	c := &Clock{now: time.Now, loc: loc, layout: layout}
+	switch {
+	case date != "":
+		t, err := parseDate(date, loc)
+		if err != nil {
+			return nil, err
+		}
+		c.now = func() time.Time { return t }
+	case epoch != "":
+		secs, err := strconv.ParseInt(strings.TrimSpace(epoch), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be a Unix timestamp", SourceDateEpochEnv, epoch)
+		}
+		t := time.Unix(secs, 0)
+		c.now = func() time.Time { return t }
+	}
+	return c, nil
+}
+
+// Fixed returns a clock that always reports t, in t's timezone, formatted
+// with DefaultLayout.
+func Fixed(t time.Time) *Clock {
+	return &Clock{now: func() time.Time { return t }, loc: t.Location(), layout: DefaultLayout}
+}
+
+// Now returns the release time in the clock's timezone.
+func (c *Clock) Now() time.Time {
+	return c.now().In(c.loc)
+}
+
+// Date returns the release date formatted with the clock's layout.
+func (c *Clock) Date() string {
+	return c.Now().Format(c.layout)
+}
+
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(DefaultLayout, s, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", s)
+}
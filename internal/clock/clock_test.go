@@ -0,0 +1,67 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name             string
+		date, tz, layout string
+		epoch            string
+		want             string
+	}{
+		{name: "date flag", date: "2025-03-01", want: "2025-03-01"},
+		{name: "date flag in timezone", date: "2025-03-01", tz: "Pacific/Auckland", want: "2025-03-01"},
+		{name: "rfc3339 converted to timezone", date: "2025-03-01T23:30:00Z", tz: "Europe/Berlin", want: "2025-03-02"},
+		{name: "epoch is UTC", epoch: "1740873600", want: "2025-03-02"},
+		{name: "epoch in timezone", epoch: "1740873600", tz: "America/New_York", want: "2025-03-01"},
+		{name: "date wins over epoch", date: "2024-12-31", epoch: "1740873600", want: "2024-12-31"},
+		{name: "layout", date: "2025-03-01", layout: "January 2, 2006", want: "March 1, 2025"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SourceDateEpochEnv, tt.epoch)
+			c, err := New(tt.date, tt.tz, tt.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Date(); got != tt.want {
+				t.Errorf("Date() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_now(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "")
+	c, err := New("", "UTC", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Date(), time.Now().UTC().Format(DefaultLayout); got != want {
+		t.Errorf("Date() = %q, want %q", got, want)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "")
+	if _, err := New("01/03/2025", "", ""); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+	if _, err := New("", "Mars/Olympus", ""); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+	t.Setenv(SourceDateEpochEnv, "yesterday")
+	if _, err := New("", "", ""); err == nil {
+		t.Error("expected an error for an invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestFixed(t *testing.T) {
+	c := Fixed(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	if got := c.Date(); got != "2025-03-01" {
+		t.Errorf("Date() = %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+// Package config loads repository settings from .helmver/config.yaml.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jordan-simonovski/helmver/internal/git"
+	"github.com/jordan-simonovski/helmver/internal/ignore"
+)
+
+// FileName is the config file name inside the .helmver directory.
+const FileName = "config.yaml"
+
+// Config holds repository-wide helmver settings. The zero value is the
+// default configuration.
+type Config struct {
+	Changelog   Changelog   `yaml:"changelog"`
+	ArtifactHub ArtifactHub `yaml:"artifactHub"`
+	Releases    Releases    `yaml:"releases"`
+	Date        Date        `yaml:"date"`
+	Staleness   Staleness   `yaml:"staleness"`
+	Versions    Versions    `yaml:"versions"`
+	Commit      Commit      `yaml:"commit"`
+}
+
+// Commit configures the release commit made by helmver apply --commit.
+type Commit struct {
+	// Message is a Go text/template for the commit message; see
+	// apply.CommitData for the fields and apply.DefaultCommitMessage for
+	// the default.
+	Message string `yaml:"message"`
+}
+
+// Versions configures the version rules of helmver check, which compare
+// each chart's version with the base ref.
+type Versions struct {
+	// NonAdjacent is how a bump that skips versions, such as 1.2.0 ->
+	// 3.0.0, is reported: "warn" (the default), "error" or "off".
+	NonAdjacent string `yaml:"nonAdjacent"`
+}
+
+// Staleness configures which file changes make a chart stale.
+type Staleness struct {
+	// Ignore lists .helmignore-style patterns, relative to each chart
+	// directory, for files whose changes never make a chart stale, such
+	// as "README.md", "ci/" or "tests/".
+	Ignore []string `yaml:"ignore"`
+	// Charts adds patterns for single charts, keyed by chart name or by
+	// path as in changeset files.
+	Charts map[string][]string `yaml:"charts"`
+	// Helmignore honours each chart's .helmignore, so only changes to
+	// files that end up in the chart package count. It defaults to true.
+	Helmignore *bool `yaml:"helmignore"`
+	// TagPattern, such as "{name}-{version}", compares each chart with its
+	// most recent release tag instead of the base ref; see
+	// git.TagPattern.
+	TagPattern string `yaml:"tagPattern"`
+}
+
+// UseHelmignore reports whether charts' .helmignore files apply.
+func (s Staleness) UseHelmignore() bool {
+	return s.Helmignore == nil || *s.Helmignore
+}
+
+// Date configures how release dates are written to changelogs and the
+// release log.
+type Date struct {
+	// Timezone is an IANA timezone name such as "UTC". When empty, dates
+	// are in local time, or in UTC when SOURCE_DATE_EPOCH is set.
+	Timezone string `yaml:"timezone"`
+	// Layout is a Go time layout; the default is "2006-01-02". Other
+	// layouts are not recognised as dates by helmver changelog show.
+	Layout string `yaml:"layout"`
+}
+
+// Releases configures the repository-level release log that helmver apply
+// updates on every run.
+type Releases struct {
+	// Path is the log file relative to the repository root, e.g.
+	// RELEASES.md. No log is written when it is empty.
+	Path string `yaml:"path"`
+	// Format is "markdown" (the default) or "json".
+	Format string `yaml:"format"`
+}
+
+// ArtifactHub configures the Artifact Hub annotations written on apply.
+type ArtifactHub struct {
+	// Changes writes the artifacthub.io/changes annotation for every
+	// released chart. Charts that already have the annotation are always
+	// updated.
+	Changes bool `yaml:"changes"`
+}
+
+// Changelog configures how CHANGELOG.md entries are written.
+type Changelog struct {
+	// Header is the top-level heading written to new CHANGELOG.md files.
+	Header string `yaml:"header"`
+	// Template is a Go text/template for one release entry.
+	Template string `yaml:"template"`
+	// TemplateFile is a path, relative to the repository root, to a file
+	// holding the entry template. It is read by Load into Template.
+	TemplateFile string `yaml:"templateFile"`
+	// RepoURL is the repository's web URL, used to link issues and PRs.
+	RepoURL string `yaml:"repoURL"`
+}
+
+// Path returns the config file path under root.
+func Path(root string) string {
+	return filepath.Join(root, ".helmver", FileName)
+}
+
+// Load reads .helmver/config.yaml under root. A missing file yields the
+// default configuration.
+func Load(root string) (*Config, error) {
+	cfg := &Config{}
+	path := Path(root)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	switch cfg.Releases.Format {
+	case "", "markdown", "json":
+	default:
+		return nil, fmt.Errorf("%s: unknown releases.format %q (use markdown or json)", path, cfg.Releases.Format)
+	}
+
+	switch cfg.Versions.NonAdjacent {
+	case "", "warn", "error", "off":
+	default:
+		return nil, fmt.Errorf("%s: unknown versions.nonAdjacent %q (use warn, error or off)", path, cfg.Versions.NonAdjacent)
+	}
+
+	if cfg.Date.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Date.Timezone); err != nil {
+			return nil, fmt.Errorf("%s: unknown date.timezone %q", path, cfg.Date.Timezone)
+		}
+	}
+
+	if err := (&ignore.Rules{}).Add(cfg.Staleness.Ignore...); err != nil {
+		return nil, fmt.Errorf("%s: staleness.ignore: %w", path, err)
+	}
+	for name, patterns := range cfg.Staleness.Charts {
+		if err := (&ignore.Rules{}).Add(patterns...); err != nil {
+			return nil, fmt.Errorf("%s: staleness.charts.%s: %w", path, name, err)
+		}
+	}
+
+	if cfg.Staleness.TagPattern != "" {
+		if _, err := git.ParseTagPattern(cfg.Staleness.TagPattern); err != nil {
+			return nil, fmt.Errorf("%s: staleness.tagPattern: %w", path, err)
+		}
+	}
+
+	if cfg.Changelog.TemplateFile != "" {
+		if cfg.Changelog.Template != "" {
+			return nil, fmt.Errorf("%s: changelog.template and changelog.templateFile are mutually exclusive", path)
+		}
+		tmplPath := cfg.Changelog.TemplateFile
+		if !filepath.IsAbs(tmplPath) {
+			tmplPath = filepath.Join(root, tmplPath)
+		}
+		tmpl, err := os.ReadFile(tmplPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading changelog template: %w", err)
+		}
+		cfg.Changelog.Template = string(tmpl)
+	}
+	return cfg, nil
+}
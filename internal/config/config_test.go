@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, root, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, ".helmver"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(root), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_missing(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Changelog != (Changelog{}) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_empty(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "")
+	if _, err := Load(root); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_changelog(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "changelog:\n  header: \"# Release notes\"\n  repoURL: https://github.com/acme/charts\n  template: |\n    ## {{.Version}}\n")
+
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Changelog.Header != "# Release notes" || cfg.Changelog.RepoURL != "https://github.com/acme/charts" {
+		t.Errorf("unexpected config: %+v", cfg.Changelog)
+	}
+	if cfg.Changelog.Template != "## {{.Version}}\n" {
+		t.Errorf("template: got %q", cfg.Changelog.Template)
+	}
+}
+
+func TestLoad_templateFile(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "changelog:\n  templateFile: .helmver/changelog.tmpl\n")
+	if err := os.WriteFile(filepath.Join(root, ".helmver", "changelog.tmpl"), []byte("## {{.Version}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Changelog.Template != "## {{.Version}}\n" {
+		t.Errorf("template: got %q", cfg.Changelog.Template)
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":    "changelog:\n  heading: x\n",
+		"both templates":   "changelog:\n  template: x\n  templateFile: y\n",
+		"missing template": "changelog:\n  templateFile: nope.tmpl\n",
+		"invalid yaml":     "changelog: [\n",
+		"unknown timezone": "date:\n  timezone: Mars/Olympus\n",
+		"bad ignore":       "staleness:\n  ignore: [\"docs/**\"]\n",
+		"bad nonAdjacent":  "versions:\n  nonAdjacent: fail\n",
+		"bad tagPattern":   "staleness:\n  tagPattern: \"{name}\"\n",
+		"bad chart ignore": "staleness:\n  charts:\n    api: [\"[a-\"]\n",
+	} {
+		root := t.TempDir()
+		writeConfig(t, root, content)
+		if _, err := Load(root); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoad_date(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "date:\n  timezone: Europe/Berlin\n  layout: 02.01.2006\n")
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Date.Timezone != "Europe/Berlin" || cfg.Date.Layout != "02.01.2006" {
+		t.Errorf("unexpected date config: %+v", cfg.Date)
+	}
+}
+
+func TestLoad_staleness(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "staleness:\n  ignore: [README.md, ci/]\n  charts:\n    api: [docs/]\n  helmignore: false\n  tagPattern: \"{name}/v{version}\"\n")
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Staleness.Ignore) != 2 || cfg.Staleness.Charts["api"][0] != "docs/" || cfg.Staleness.UseHelmignore() || cfg.Staleness.TagPattern != "{name}/v{version}" {
+		t.Errorf("unexpected staleness config: %+v", cfg.Staleness)
+	}
+	if !(&Config{}).Staleness.UseHelmignore() {
+		t.Error(".helmignore should be used by default")
+	}
+}
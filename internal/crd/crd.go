@@ -0,0 +1,197 @@
+// Package crd compares the CustomResourceDefinitions a chart ships in its
+// crds/ directory. Helm installs those once and never upgrades them, so
+// users of a new chart version keep the old CRDs until they apply the new
+// ones by hand; a change that rejects existing custom resources is
+// breaking.
+package crd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jordan-simonovski/helmver/internal/values"
+)
+
+// Dir is the chart directory Helm installs CRDs from.
+const Dir = "crds"
+
+// IsFile reports whether name, relative to the chart directory, is a file
+// Helm installs as CRDs: a .yaml, .yml or .json file under crds/.
+func IsFile(name string) bool {
+	if !strings.HasPrefix(name, Dir+"/") {
+		return false
+	}
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Set holds CRDs by name (metadata.name, such as widgets.example.com).
+type Set map[string]map[string]any
+
+// Add parses a CRD file, which may hold several YAML documents. Documents
+// that are not CustomResourceDefinitions are skipped.
+func (s Set) Add(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]any
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if doc["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+		meta, _ := doc["metadata"].(map[string]any)
+		name, _ := meta["name"].(string)
+		if name == "" {
+			return fmt.Errorf("CustomResourceDefinition without metadata.name")
+		}
+		s[name] = doc
+	}
+}
+
+// Change is one difference between two versions of a CRD.
+type Change struct {
+	CRD      string // metadata.name
+	Version  string // the CRD version changed, or "" for the whole CRD
+	Message  string // e.g. "removed field spec.size"
+	Breaking bool   // existing custom resources may be rejected
+}
+
+func (c Change) String() string {
+	if c.Version == "" {
+		return c.CRD + ": " + c.Message
+	}
+	return c.CRD + " " + c.Version + ": " + c.Message
+}
+
+// Diff compares the CRDs at the base ref with those at HEAD. Removed
+// CRDs, removed or no longer served versions, a changed scope, and schema
+// changes that reject existing resources (removed fields, narrowed types,
+// new required fields, stricter validation) are breaking. Anything else
+// that changed, down to descriptions, is reported as not breaking.
+func Diff(base, head Set) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(base) {
+		h, ok := head[name]
+		if !ok {
+			changes = append(changes, Change{CRD: name, Message: "removed", Breaking: true})
+			continue
+		}
+		changes = append(changes, diffCRD(name, base[name], h)...)
+	}
+	for _, name := range sortedKeys(head) {
+		if _, ok := base[name]; !ok {
+			changes = append(changes, Change{CRD: name, Message: "added"})
+		}
+	}
+	return changes
+}
+
+func diffCRD(name string, base, head map[string]any) []Change {
+	var changes []Change
+	add := func(version, msg string, breaking bool) {
+		changes = append(changes, Change{CRD: name, Version: version, Message: msg, Breaking: breaking})
+	}
+
+	bs, hs := field(base, "spec"), field(head, "spec")
+	if b, h := bs["scope"], hs["scope"]; b != h {
+		add("", fmt.Sprintf("scope changed from %v to %v", b, h), true)
+	}
+
+	bv, hv := versions(bs), versions(hs)
+	for _, v := range sortedKeys(bv) {
+		h, ok := hv[v]
+		if !ok {
+			add(v, "removed version", true)
+			continue
+		}
+		if bv[v]["served"] == true && h["served"] != true {
+			add(v, "version no longer served", true)
+		}
+		if bv[v]["storage"] != h["storage"] && h["storage"] == true {
+			add(v, "is now the storage version", false)
+		}
+		bSchema := field(field(bv[v], "schema"), "openAPIV3Schema")
+		hSchema := field(field(hv[v], "schema"), "openAPIV3Schema")
+		for _, c := range values.CompareSchema(bSchema, hSchema) {
+			add(v, fieldChange(c), c.Breaking())
+		}
+	}
+	for _, v := range sortedKeys(hv) {
+		if _, ok := bv[v]; !ok {
+			add(v, "added version", false)
+		}
+	}
+
+	if len(changes) == 0 && !reflect.DeepEqual(base, head) {
+		add("", "changed", false)
+	}
+	return changes
+}
+
+// fieldChange describes a schema change in terms of custom resource
+// fields.
+func fieldChange(c values.Change) string {
+	switch c.Kind {
+	case values.TypeChanged:
+		return fmt.Sprintf("changed type of field %s (%s)", fieldPath(c.Path), c.Detail)
+	case values.Required:
+		return fmt.Sprintf("made field %s required", fieldPath(c.Path))
+	case values.Tightened:
+		return fmt.Sprintf("tightened validation of field %s (%s)", fieldPath(c.Path), c.Detail)
+	default:
+		return fmt.Sprintf("%s field %s", c.Kind, fieldPath(c.Path))
+	}
+}
+
+func fieldPath(p string) string {
+	if p == "" {
+		return "(root)"
+	}
+	return p
+}
+
+// versions returns spec.versions by name.
+func versions(spec map[string]any) map[string]map[string]any {
+	list, _ := spec["versions"].([]any)
+	out := make(map[string]map[string]any, len(list))
+	for _, x := range list {
+		v, _ := x.(map[string]any)
+		if name, ok := v["name"].(string); ok {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// field returns the map at key, or an empty map.
+func field(m map[string]any, key string) map[string]any {
+	if v, ok := m[key].(map[string]any); ok {
+		return v
+	}
+	return map[string]any{}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package crd
+
+import (
+	"reflect"
+	"testing"
+)
+
+const widgetsV1 = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names: {kind: Widget, plural: widgets}
+  versions:
+    - name: v1alpha1
+      served: true
+      storage: false
+      schema:
+        openAPIV3Schema: {type: object}
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                size: {type: integer, maximum: 10}
+                color: {type: string}
+`
+
+func parse(t *testing.T, docs ...string) Set {
+	t.Helper()
+	s := Set{}
+	for _, d := range docs {
+		if err := s.Add([]byte(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestDiff_breaking(t *testing.T) {
+	head := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names: {kind: Widget, plural: widgets}
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              required: [size]
+              properties:
+                size: {type: integer, maximum: 5}
+                shape: {type: string}
+`
+	got := Diff(parse(t, widgetsV1), parse(t, head))
+	want := []Change{
+		{CRD: "widgets.example.com", Version: "v1", Message: "made field spec.size required", Breaking: true},
+		{CRD: "widgets.example.com", Version: "v1", Message: "removed field spec.color", Breaking: true},
+		{CRD: "widgets.example.com", Version: "v1", Message: "tightened validation of field spec.size (maximum 10 -> 5)", Breaking: true},
+		{CRD: "widgets.example.com", Version: "v1", Message: "added field spec.shape"},
+		{CRD: "widgets.example.com", Version: "v1alpha1", Message: "removed version", Breaking: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestDiff_notBreaking(t *testing.T) {
+	head := widgetsV1 + `    - name: v2
+      served: true
+      storage: false
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  scope: Cluster
+`
+	got := Diff(parse(t, widgetsV1), parse(t, head))
+	want := []Change{
+		{CRD: "widgets.example.com", Version: "v2", Message: "added version"},
+		{CRD: "gadgets.example.com", Message: "added"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%+v\nwant:\n%+v", got, want)
+	}
+
+	relabelled := parse(t, widgetsV1)
+	relabelled["widgets.example.com"]["metadata"].(map[string]any)["labels"] = map[string]any{"app": "x"}
+	got = Diff(parse(t, widgetsV1), relabelled)
+	if len(got) != 1 || got[0].Message != "changed" || got[0].Breaking {
+		t.Errorf("got %+v, want one non-breaking change", got)
+	}
+}
+
+func TestDiff_removedCRD(t *testing.T) {
+	got := Diff(parse(t, widgetsV1), Set{})
+	if len(got) != 1 || !got[0].Breaking || got[0].String() != "widgets.example.com: removed" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSet_Add(t *testing.T) {
+	s := Set{}
+	if err := s.Add([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n---\n" + widgetsV1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 1 || s["widgets.example.com"] == nil {
+		t.Errorf("got %v", s)
+	}
+	if err := s.Add([]byte("kind: CustomResourceDefinition\nmetadata: {}\n")); err == nil {
+		t.Error("expected an error for a CRD without a name")
+	}
+}
+
+func TestIsFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"crds/widgets.yaml":    true,
+		"crds/sub/gadgets.yml": true,
+		"crds/all.json":        true,
+		"crds/README.md":       false,
+		"templates/crd.yaml":   false,
+	} {
+		if got := IsFile(name); got != want {
+			t.Errorf("IsFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package diff renders line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff from before to after, labelled with the
+// given file names, or "" when the contents are equal. A nil before or
+// after marks a created or deleted file and is labelled /dev/null.
+func Unified(fromName, toName string, before, after []byte) string {
+	if string(before) == string(after) && (before == nil) == (after == nil) {
+		return ""
+	}
+	if before == nil {
+		fromName = "/dev/null"
+	}
+	if after == nil {
+		toName = "/dev/null"
+	}
+
+	ops := lineDiff(splitLines(string(before)), splitLines(string(after)))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops) {
+		writeHunk(&b, ops, h)
+	}
+	return b.String()
+}
+
+// splitLines splits s into lines, keeping each line's terminator so that
+// a missing final newline shows up as a change.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineDiff computes an edit script from a to b. Common leading and trailing
+// lines are stripped first; the remaining middle is diffed with a longest
+// common subsequence table, which stays small for the localized edits
+// helmver makes.
+func lineDiff(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		ops = append(ops, op{opEqual, l})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, op{opEqual, ma[i]})
+			i++
+			j++
+		case j < len(mb) && (i == len(ma) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{opInsert, mb[j]})
+			j++
+		default:
+			ops = append(ops, op{opDelete, ma[i]})
+			i++
+		}
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, l})
+	}
+	return ops
+}
+
+// hunk is a half-open range of ops to print.
+type hunk struct{ start, end int }
+
+// hunks groups changed ops with Context lines of surrounding context,
+// merging groups whose context overlaps.
+func hunks(ops []op) []hunk {
+	var out []hunk
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		start := max(i-Context, 0)
+		end := min(i+1+Context, len(ops))
+		if n := len(out); n > 0 && start <= out[n-1].end {
+			out[n-1].end = end
+			continue
+		}
+		out = append(out, hunk{start, end})
+	}
+	return out
+}
+
+func writeHunk(b *strings.Builder, ops []op, h hunk) {
+	// Line numbers of the hunk's first line in each file.
+	aLine, bLine := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != opInsert {
+			aLine++
+		}
+		if o.kind != opDelete {
+			bLine++
+		}
+	}
+	aCount, bCount := 0, 0
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+	for _, o := range ops[h.start:h.end] {
+		prefix := " "
+		switch o.kind {
+		case opDelete:
+			prefix = "-"
+		case opInsert:
+			prefix = "+"
+		}
+		b.WriteString(prefix)
+		b.WriteString(strings.TrimSuffix(o.line, "\n"))
+		b.WriteString("\n")
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's line range; an empty range starts at the line
+// before it, as in GNU diff.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
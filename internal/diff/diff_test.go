@@ -0,0 +1,70 @@
+package diff
+
+import "testing"
+
+func TestUnified_equal(t *testing.T) {
+	if got := Unified("a", "b", []byte("x\n"), []byte("x\n")); got != "" {
+		t.Errorf("expected no diff, got:\n%s", got)
+	}
+}
+
+func TestUnified_modify(t *testing.T) {
+	before := "apiVersion: v2\nname: api\nversion: 1.0.0\ndescription: API\ntype: application\nkeywords: []\nhome: x\n"
+	after := "apiVersion: v2\nname: api\nversion: 1.1.0\ndescription: API\ntype: application\nkeywords: []\nhome: x\n"
+	want := `--- a/Chart.yaml
++++ b/Chart.yaml
+@@ -1,6 +1,6 @@
+ apiVersion: v2
+ name: api
+-version: 1.0.0
++version: 1.1.0
+ description: API
+ type: application
+ keywords: []
+`
+	if got := Unified("a/Chart.yaml", "b/Chart.yaml", []byte(before), []byte(after)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_separateHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+	want := `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -7,4 +7,4 @@
+ 7
+ 8
+ 9
+-10
++ten
+`
+	if got := Unified("a", "b", []byte(before), []byte(after)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_createAndDelete(t *testing.T) {
+	want := "--- /dev/null\n+++ b/CHANGELOG.md\n@@ -0,0 +1,2 @@\n+# Changelog\n+\n"
+	if got := Unified("a/CHANGELOG.md", "b/CHANGELOG.md", nil, []byte("# Changelog\n\n")); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	want = "--- a/x.md\n+++ /dev/null\n@@ -1 +0,0 @@\n-gone\n"
+	if got := Unified("a/x.md", "b/x.md", []byte("gone\n"), nil); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_noTrailingNewline(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -1 +1 @@\n-v\n\\ No newline at end of file\n+w\n\\ No newline at end of file\n"
+	if got := Unified("a", "b", []byte("v"), []byte("w")); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,252 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jordan-simonovski/helmver/internal/ignore"
+)
+
+// ChartRef identifies a chart for StaleCharts.
+type ChartRef struct {
+	Dir     string // directory containing Chart.yaml
+	File    string // path to Chart.yaml
+	Version string // current version
+	// Ignore selects files, relative to Dir, whose changes do not count;
+	// nil counts every file.
+	Ignore *ignore.Rules
+	// Base is the ref to compare this chart with, such as its last
+	// release tag; empty means the base ref StaleCharts is given.
+	Base string
+}
+
+// Mode selects the changes StaleCharts looks at.
+type Mode int
+
+const (
+	// Committed compares HEAD with the base ref (<base>...HEAD).
+	Committed Mode = iota
+	// Staged compares the index with the merge-base of the base ref and
+	// HEAD, so it sees the commits since the base plus the staged changes,
+	// and reads the current Chart.yaml version from the index. With HEAD
+	// as the base ref it sees the staged changes only.
+	Staged
+	// WorkingTree compares the working tree, including untracked files
+	// that are not gitignored, with the merge-base of the base ref and
+	// HEAD.
+	WorkingTree
+)
+
+// StaleCharts is IsStale for many charts at once. Instead of several git
+// processes per chart it runs three in total:
+//
+//	git diff --name-only -z <base>...HEAD
+//	git merge-base <base> HEAD
+//	git cat-file --batch    (<base>:<chart>/Chart.yaml for every changed chart)
+//
+// Staged and WorkingTree mode diff the index or working tree against the
+// merge-base instead, and WorkingTree mode also lists untracked files.
+// Charts with their own Base add a diff and merge-base per distinct ref.
+//
+// Each changed file belongs to the chart with the deepest directory
+// containing it, so a change inside a subchart marks only the subchart
+// stale, not its parent. Files outside every chart, and files that a
+// chart's Ignore rules leave out of its package, are ignored. The result
+// has one entry per chart, in order.
+func StaleCharts(repoRoot, baseRef string, mode Mode, charts []ChartRef) ([]StaleResult, error) {
+	repoRoot, err := filepath.EvalSymlinks(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	relDirs := make([]string, len(charts))
+	relFiles := make([]string, len(charts))
+	owner := make(map[string]int, len(charts))
+	for i, c := range charts {
+		if relDirs[i], err = RelPath(repoRoot, c.Dir); err != nil {
+			return nil, err
+		}
+		if relFiles[i], err = RelPath(repoRoot, c.File); err != nil {
+			return nil, err
+		}
+		owner[relDirs[i]] = i
+	}
+
+	results := make([]StaleResult, len(charts))
+	var bases []string
+	for i, c := range charts {
+		results[i].Version = c.Version
+		results[i].Base = c.Base
+		if results[i].Base == "" {
+			results[i].Base = baseRef
+		}
+		if !contains(bases, results[i].Base) {
+			bases = append(bases, results[i].Base)
+		}
+	}
+
+	mergeBases := make(map[string]string, len(bases))
+	for _, b := range bases {
+		var files []string
+		if mode == Committed {
+			files, err = changedFiles(repoRoot, b)
+			if err != nil {
+				return nil, fmt.Errorf("diff %s...HEAD: %w", b, err)
+			}
+		} else {
+			if mergeBases[b], err = MergeBase(repoRoot, b); err != nil {
+				return nil, err
+			}
+			if files, err = localChanges(repoRoot, mergeBases[b], mode); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range files {
+			i, ok := ownerOf(owner, f)
+			if !ok || results[i].Base != b || charts[i].Ignore.Ignored(ChartRelative(relDirs[i], f)) {
+				continue
+			}
+			results[i].ChangedFiles = append(results[i].ChangedFiles, f)
+		}
+	}
+
+	var want []int
+	var specs []string
+	for i := range charts {
+		if len(results[i].ChangedFiles) > 0 {
+			want = append(want, i)
+			specs = append(specs, results[i].Base+":"+relFiles[i])
+		}
+	}
+	if len(want) == 0 {
+		return results, nil
+	}
+	if mode == Staged {
+		// ":<path>" names the staged blob.
+		for _, i := range want {
+			specs = append(specs, ":"+relFiles[i])
+		}
+	}
+	blobs, err := catFiles(repoRoot, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, i := range want {
+		b := results[i].Base
+		if _, ok := mergeBases[b]; !ok {
+			if mergeBases[b], err = MergeBase(repoRoot, b); err != nil {
+				return nil, err
+			}
+		}
+		results[i].MergeBase = mergeBases[b]
+		if mode == Staged && blobs[len(want)+k] != nil {
+			if v, ok := parseVersion(blobs[len(want)+k]); ok {
+				results[i].Version = v
+			}
+		}
+		if blobs[k] == nil {
+			// Chart doesn't exist in base ref → new chart, not stale.
+			continue
+		}
+		if baseVer, ok := parseVersion(blobs[k]); ok {
+			results[i].BaseVersion = baseVer
+			results[i].Stale = results[i].Version == baseVer
+		}
+	}
+	return results, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RelPath returns p relative to repoRoot in git's slash-separated form,
+// resolving symlinks first; see IsStale.
+func RelPath(repoRoot, p string) (string, error) {
+	p, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(repoRoot, p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// ownerOf returns the chart with the deepest directory containing file.
+func ownerOf(owner map[string]int, file string) (int, bool) {
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		if i, ok := owner[dir]; ok {
+			return i, true
+		}
+		if dir == "." || dir == "/" {
+			return 0, false
+		}
+	}
+}
+
+// ChartRelative returns file, relative to the repository root, relative to
+// the chart directory relDir instead. Both paths are slash-separated.
+func ChartRelative(relDir, file string) string {
+	if relDir == "." {
+		return file
+	}
+	return strings.TrimPrefix(file, relDir+"/")
+}
+
+// catFiles reads several "<ref>:<path>" blobs with one git cat-file
+// process. Missing objects are returned as nil.
+func catFiles(repoRoot string, specs []string) ([][]byte, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	cmd := exec.Command("git", "-C", repoRoot, "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(strings.Join(specs, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+
+	r := bufio.NewReader(bytes.NewReader(out))
+	blobs := make([][]byte, len(specs))
+	for i, spec := range specs {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file --batch: reading %s: %w", spec, err)
+		}
+		// "<sha> <type> <size>", or "<spec> missing" / "<spec> ambiguous".
+		if strings.HasSuffix(header, " missing\n") || strings.HasSuffix(header, " ambiguous\n") {
+			continue
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("git cat-file --batch: bad header %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file --batch: bad header %q", header)
+		}
+		data := make([]byte, size+1) // contents plus trailing newline
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("git cat-file --batch: reading %s: %w", spec, err)
+		}
+		if fields[1] == "blob" {
+			blobs[i] = data[:size]
+		}
+	}
+	return blobs, nil
+}
@@ -0,0 +1,188 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaleCharts(t *testing.T) {
+	dir := initGitRepo(t)
+	chart := func(rel, version string) ChartRef {
+		d := filepath.Join(dir, rel)
+		f := filepath.Join(d, "Chart.yaml")
+		writeFile(t, f, "apiVersion: v2\nname: x\nversion: "+version+"\n")
+		writeFile(t, filepath.Join(d, "values.yaml"), "key: val\n")
+		return ChartRef{Dir: d, File: f, Version: version}
+	}
+	root := chart(".", "1.0.0")
+	redis := chart("charts/redis", "0.1.0")
+	api := chart("services/api", "1.2.0")
+	web := chart("services/web", "2.0.0")
+	idle := chart("services/idle", "0.3.0")
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "docs\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "initial")
+	run(t, dir, "git", "branch", "base")
+
+	// redis changes without a bump: only redis is stale, not the root
+	// chart that contains it.
+	writeFile(t, filepath.Join(dir, "charts", "redis", "values.yaml"), "key: new\n")
+	// api changes without a bump.
+	writeFile(t, filepath.Join(dir, "services", "api", "templates", "svc.yaml"), "kind: Service\n")
+	// web changes with a bump.
+	writeFile(t, filepath.Join(dir, "services", "web", "values.yaml"), "key: new\n")
+	writeFile(t, web.File, "apiVersion: v2\nname: x\nversion: 2.1.0\n")
+	web.Version = "2.1.0"
+	// A new chart is never stale.
+	added := chart("services/new", "0.1.0")
+	// Files outside charts (other than the root chart) belong to the root.
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "more docs\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "changes")
+
+	refs := []ChartRef{root, redis, api, web, idle, added}
+	got, err := StaleCharts(dir, "base", Committed, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{true, true, true, false, false, false}
+	for i := range want {
+		if got[i].Stale != want[i] {
+			t.Errorf("%s: stale = %v, want %v", refs[i].Dir, got[i].Stale, want[i])
+		}
+	}
+
+	if files := got[1].ChangedFiles; len(files) != 1 || files[0] != "charts/redis/values.yaml" {
+		t.Errorf("redis changed files = %v", files)
+	}
+	if files := got[0].ChangedFiles; len(files) != 1 || files[0] != "docs/README.md" {
+		t.Errorf("root changed files = %v", files)
+	}
+	if got[3].BaseVersion != "2.0.0" || got[5].BaseVersion != "" {
+		t.Errorf("base versions = %q, %q", got[3].BaseVersion, got[5].BaseVersion)
+	}
+	if got[1].MergeBase == "" || got[1].MergeBase != got[2].MergeBase {
+		t.Errorf("merge bases = %q, %q", got[1].MergeBase, got[2].MergeBase)
+	}
+	if got[4].ChangedFiles != nil || got[4].MergeBase != "" {
+		t.Errorf("unchanged chart has details: %+v", got[4])
+	}
+}
+
+func TestStaleCharts_localModes(t *testing.T) {
+	dir := initGitRepo(t)
+	chart := func(rel string) ChartRef {
+		d := filepath.Join(dir, rel)
+		f := filepath.Join(d, "Chart.yaml")
+		writeFile(t, f, "apiVersion: v2\nname: x\nversion: 1.0.0\n")
+		writeFile(t, filepath.Join(d, "values.yaml"), "key: val\n")
+		return ChartRef{Dir: d, File: f, Version: "1.0.0"}
+	}
+	api, web, db := chart("api"), chart("web"), chart("db")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "initial")
+
+	// api: staged change; its bump is on disk but not staged.
+	writeFile(t, filepath.Join(dir, "api", "values.yaml"), "key: new\n")
+	run(t, dir, "git", "add", "api/values.yaml")
+	writeFile(t, api.File, "apiVersion: v2\nname: x\nversion: 1.1.0\n")
+	api.Version = "1.1.0"
+	// web: unstaged change.
+	writeFile(t, filepath.Join(dir, "web", "values.yaml"), "key: new\n")
+	// db: untracked file.
+	writeFile(t, filepath.Join(dir, "db", "templates", "svc.yaml"), "kind: Service\n")
+
+	refs := []ChartRef{api, web, db}
+	for _, tt := range []struct {
+		mode Mode
+		want []bool
+	}{
+		{Committed, []bool{false, false, false}},
+		{Staged, []bool{true, false, false}},
+		{WorkingTree, []bool{false, true, true}},
+	} {
+		got, err := StaleCharts(dir, "HEAD", tt.mode, refs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range tt.want {
+			if got[i].Stale != tt.want[i] {
+				t.Errorf("mode %d: %s: stale = %v, want %v", tt.mode, refs[i].Dir, got[i].Stale, tt.want[i])
+			}
+		}
+		if tt.mode == Staged && got[0].Version != "1.0.0" {
+			t.Errorf("staged version = %q, want the index version 1.0.0", got[0].Version)
+		}
+	}
+}
+
+func TestStaleCharts_matchesIsStale(t *testing.T) {
+	dir := initGitRepo(t)
+	refs := setupCharts(t, dir, 6)
+
+	got, err := StaleCharts(dir, "base", Committed, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range refs {
+		want, err := IsStale(dir, c.Dir, c.File, "base", c.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[i].Stale != want.Stale || got[i].BaseVersion != want.BaseVersion || len(got[i].ChangedFiles) != len(want.ChangedFiles) {
+			t.Errorf("%s: StaleCharts = %+v, IsStale = %+v", c.Dir, got[i], want)
+		}
+	}
+}
+
+// setupCharts commits n sibling charts on a "base" branch, then changes
+// every other one, bumping every fourth, so a quarter of them end up stale.
+func setupCharts(tb testing.TB, dir string, n int) []ChartRef {
+	tb.Helper()
+	refs := make([]ChartRef, n)
+	for i := range refs {
+		d := filepath.Join(dir, "charts", fmt.Sprintf("chart-%03d", i))
+		refs[i] = ChartRef{Dir: d, File: filepath.Join(d, "Chart.yaml"), Version: "1.0.0"}
+		writeFile(tb, refs[i].File, "apiVersion: v2\nname: x\nversion: 1.0.0\n")
+		writeFile(tb, filepath.Join(d, "values.yaml"), "key: val\n")
+	}
+	run(tb, dir, "git", "add", "-A")
+	run(tb, dir, "git", "commit", "-q", "-m", "initial")
+	run(tb, dir, "git", "branch", "base")
+
+	for i := 0; i < n; i += 2 {
+		writeFile(tb, filepath.Join(refs[i].Dir, "values.yaml"), "key: new\n")
+		if i%4 == 0 {
+			writeFile(tb, refs[i].File, "apiVersion: v2\nname: x\nversion: 1.1.0\n")
+			refs[i].Version = "1.1.0"
+		}
+	}
+	run(tb, dir, "git", "add", "-A")
+	run(tb, dir, "git", "commit", "-q", "-m", "changes")
+	return refs
+}
+
+// BenchmarkStaleness compares one IsStale call per chart with a single
+// StaleCharts call on a repository of 100 charts.
+func BenchmarkStaleness(b *testing.B) {
+	dir := initGitRepo(b)
+	refs := setupCharts(b, dir, 100)
+
+	b.Run("IsStale", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, c := range refs {
+				if _, err := IsStale(dir, c.Dir, c.File, "base", c.Version); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("StaleCharts", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := StaleCharts(dir, "base", Committed, refs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Commit stages the given files, which may have been created, modified or
+// deleted, and commits exactly those files with message: anything else
+// already staged is left staged but not committed. Deleted files that git
+// never tracked are skipped.
+func Commit(repoRoot string, paths []string, message string) error {
+	rels := make([]string, 0, len(paths))
+	for _, p := range paths {
+		// A deleted file cannot be resolved, so resolve its directory.
+		dir, err := RelPath(repoRoot, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		rels = append(rels, filepath.ToSlash(filepath.Join(dir, filepath.Base(p))))
+	}
+
+	out, err := exec.Command("git", append([]string{"-C", repoRoot, "ls-files", "-z", "--"}, rels...)...).Output()
+	if err != nil {
+		return fmt.Errorf("git ls-files: %w", err)
+	}
+	tracked := make(map[string]bool)
+	for _, f := range strings.Split(string(out), "\x00") {
+		tracked[f] = true
+	}
+	var files []string
+	for i, rel := range rels {
+		if _, err := os.Stat(paths[i]); err == nil || tracked[rel] {
+			files = append(files, rel)
+		}
+	}
+
+	if err := runGit(repoRoot, nil, append([]string{"add", "-A", "--"}, files...)...); err != nil {
+		return err
+	}
+	return runGit(repoRoot, strings.NewReader(message), append([]string{"commit", "-q", "--cleanup=whitespace", "-F", "-", "--only", "--"}, files...)...)
+}
+
+// runGit runs a git command that changes the repository, with git's own
+// message as the error.
+func runGit(repoRoot string, stdin io.Reader, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", repoRoot}, args...)...)
+	cmd.Stdin = stdin
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
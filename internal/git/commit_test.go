@@ -0,0 +1,82 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommit(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "a"), "1\n")
+	writeFile(t, filepath.Join(dir, "b"), "2\n")
+	writeFile(t, filepath.Join(dir, "c"), "1\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-q", "-m", "initial")
+
+	// c is staged by someone else and must stay out of the commit.
+	writeFile(t, filepath.Join(dir, "c"), "2\n")
+	run(t, dir, "git", "add", "c")
+	writeFile(t, filepath.Join(dir, "a"), "2\n")
+	if err := os.Remove(filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "sub", "d"), "1\n")
+	// e was never tracked and is already gone.
+	paths := []string{"a", "b", "sub/d", "e"}
+	for i, p := range paths {
+		paths[i] = filepath.Join(dir, p)
+	}
+
+	if err := Commit(dir, paths, "release\n\n- a\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "show", "--name-status", "--no-renames", "--format=%B", "HEAD"); got != "release\n\n- a\n\n\nM\ta\nD\tb\nA\tsub/d" {
+		t.Errorf("commit:\n%s", got)
+	}
+	if got := gitOutput(t, dir, "diff", "--cached", "--name-only"); got != "c" {
+		t.Errorf("still staged: %q, want c", got)
+	}
+
+	if err := Commit(dir, []string{filepath.Join(dir, "a")}, "nothing"); err == nil {
+		t.Error("expected an error when there is nothing to commit")
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "a"), "1\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-q", "-m", "initial")
+
+	tag := TagPattern("{name}/v{version}").Format("api", "1.2.0")
+	if tag != "api/v1.2.0" {
+		t.Fatalf("Format = %q", tag)
+	}
+	if TagExists(dir, tag) {
+		t.Fatal("tag exists before it was created")
+	}
+	if err := CreateTag(dir, tag, "## 1.2.0\n\n- Fixed it\n"); err != nil {
+		t.Fatal(err)
+	}
+	if !TagExists(dir, tag) {
+		t.Error("tag was not created")
+	}
+	if got := gitOutput(t, dir, "tag", "-l", "--format=%(objecttype) %(contents)", tag); got != "tag ## 1.2.0\n\n- Fixed it" {
+		t.Errorf("tag = %q", got)
+	}
+	if err := CreateTag(dir, tag, "again"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for an existing tag, got %v", err)
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
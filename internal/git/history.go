@@ -0,0 +1,28 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// VersionCommit returns the newest commit in HEAD's history that added or
+// removed a top-level "version: <version>" line in chartFile, or "" when
+// the version never appears. It answers "was this version released
+// before?" without checking out old revisions:
+//
+//	git log -E -G '^version:\s*["']?1\.2\.3["']?\s*(#.*)?$' -- Chart.yaml
+func VersionCommit(chartFile, version string) (string, error) {
+	dir := filepath.Dir(chartFile)
+	pattern := `^version:[[:space:]]*["']?` + regexp.QuoteMeta(version) + `["']?[[:space:]]*(#.*)?$`
+	cmd := exec.Command("git", "-C", dir,
+		"log", "-1", "--format=%H", "-E", "-G", pattern, "--", filepath.Base(chartFile),
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git log -G for %s: %w", chartFile, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionCommit(t *testing.T) {
+	dir := initGitRepo(t)
+	chartFile := filepath.Join(dir, "charts", "api", "Chart.yaml")
+	writeFile(t, chartFile, "apiVersion: v2\nname: api\nversion: 1.0.0\ndependencies:\n  - name: common\n    version: 2.0.0\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "initial")
+	writeFile(t, chartFile, "apiVersion: v2\nname: api\nversion: \"1.1.0\" # bumped\ndependencies:\n  - name: common\n    version: 2.0.0\n")
+	run(t, dir, "git", "commit", "-am", "bump")
+
+	for _, tc := range []struct {
+		version string
+		found   bool
+	}{
+		{"1.0.0", true},
+		{"1.1.0", true},
+		{"1.2.0", false},
+		{"2.0.0", false}, // only a dependency version
+		{"1.0", false},
+	} {
+		commit, err := VersionCommit(chartFile, tc.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (commit != "") != tc.found {
+			t.Errorf("VersionCommit(%q) = %q, want found=%v", tc.version, commit, tc.found)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // IsRepo returns true if dir is inside a git repository.
@@ -33,6 +35,27 @@ func RefExists(repoRoot, ref string) bool {
 	return cmd.Run() == nil
 }
 
+// StaleResult is the outcome of a staleness check for one chart.
+type StaleResult struct {
+	Stale bool
+	// ChangedFiles lists the files that changed under the chart since the
+	// merge-base, relative to the repository root.
+	ChangedFiles []string
+	// BaseVersion is the version in Chart.yaml at the base ref; it is
+	// empty for a new chart or when nothing changed.
+	BaseVersion string
+	// MergeBase is the merge-base of the base ref and HEAD that files were
+	// compared against; it is empty when nothing changed.
+	MergeBase string
+	// Base is the ref the chart was compared with. IsStale leaves it
+	// empty.
+	Base string
+	// Version is the current version that BaseVersion was compared with:
+	// the staged one in Staged mode, otherwise the one StaleCharts was
+	// given. IsStale leaves it empty.
+	Version string
+}
+
 // IsStale reports whether a chart has file changes relative to baseRef
 // without a corresponding version bump in Chart.yaml. This mirrors the
 // common CI pattern:
@@ -42,63 +65,117 @@ func RefExists(repoRoot, ref string) bool {
 //
 // A chart is stale when files changed but the version field did not.
 // A chart that does not exist in baseRef (new chart) is never stale.
-func IsStale(repoRoot, chartDir, chartFile, baseRef, currentVersion string) (bool, error) {
+func IsStale(repoRoot, chartDir, chartFile, baseRef, currentVersion string) (StaleResult, error) {
+	var res StaleResult
 	var err error
 
 	// Resolve symlinks so paths are comparable with git's resolved toplevel.
 	// On macOS, /var -> /private/var breaks filepath.Rel without this.
 	repoRoot, err = filepath.EvalSymlinks(repoRoot)
 	if err != nil {
-		return false, err
+		return res, err
 	}
 	chartFile, err = filepath.EvalSymlinks(chartFile)
 	if err != nil {
-		return false, err
+		return res, err
 	}
 	chartDir, err = filepath.EvalSymlinks(chartDir)
 	if err != nil {
-		return false, err
+		return res, err
 	}
 
 	relDir, err := filepath.Rel(repoRoot, chartDir)
 	if err != nil {
-		return false, err
+		return res, err
 	}
 	relFile, err := filepath.Rel(repoRoot, chartFile)
 	if err != nil {
-		return false, err
+		return res, err
 	}
 
 	// 1. Any files changed between baseRef and HEAD?
-	changed, err := hasChangedFiles(repoRoot, baseRef, relDir)
+	res.ChangedFiles, err = changedFiles(repoRoot, baseRef, relDir)
 	if err != nil {
-		return false, fmt.Errorf("diff %s...HEAD -- %s: %w", baseRef, relDir, err)
+		return res, fmt.Errorf("diff %s...HEAD -- %s: %w", baseRef, relDir, err)
 	}
-	if !changed {
-		return false, nil
+	if len(res.ChangedFiles) == 0 {
+		return res, nil
+	}
+	res.MergeBase, err = MergeBase(repoRoot, baseRef)
+	if err != nil {
+		return res, err
 	}
 
 	// 2. Files changed; compare versions.
-	baseVer, err := showVersion(repoRoot, baseRef, relFile)
+	res.BaseVersion, err = showVersion(repoRoot, baseRef, relFile)
 	if err != nil {
 		// Chart doesn't exist in base ref → new chart, not stale.
-		return false, nil
+		res.BaseVersion = ""
+		return res, nil
 	}
 
-	return currentVersion == baseVer, nil
+	res.Stale = currentVersion == res.BaseVersion
+	return res, nil
 }
 
-// hasChangedFiles returns true if any files under relDir differ between
-// the merge-base of baseRef/HEAD and HEAD (three-dot diff).
-func hasChangedFiles(repoRoot, baseRef, relDir string) (bool, error) {
-	cmd := exec.Command("git", "-C", repoRoot,
-		"diff", "--name-only", baseRef+"...HEAD", "--", relDir,
-	)
+// MergeBase returns the merge-base of baseRef and HEAD, the commit that
+// three-dot diffs compare against.
+func MergeBase(repoRoot, baseRef string) (string, error) {
+	cmd := exec.Command("git", "-C", repoRoot, "merge-base", baseRef, "HEAD")
 	out, err := cmd.Output()
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("git merge-base %s HEAD: %w", baseRef, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// changedFiles lists the files that differ between the merge-base of
+// baseRef/HEAD and HEAD (three-dot diff), relative to repoRoot. Pathspecs
+// limit the diff; with none, the whole repository is compared.
+func changedFiles(repoRoot, baseRef string, pathspecs ...string) ([]string, error) {
+	args := []string{"-C", repoRoot, "diff", "--name-only", "-z", baseRef + "...HEAD"}
+	if len(pathspecs) > 0 {
+		args = append(append(args, "--"), pathspecs...)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// localChanges lists the files that differ between commit and the index
+// (Staged) or the working tree (WorkingTree), relative to repoRoot. The
+// working tree includes untracked files that are not ignored.
+func localChanges(repoRoot, commit string, mode Mode) ([]string, error) {
+	args := []string{"-C", repoRoot, "diff", "--name-only", "-z"}
+	if mode == Staged {
+		args = append(args, "--cached")
+	}
+	out, err := exec.Command("git", append(args, commit)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", commit, err)
+	}
+	if mode == WorkingTree {
+		untracked, err := exec.Command("git", "-C", repoRoot, "ls-files", "-z", "--others", "--exclude-standard").Output()
+		if err != nil {
+			return nil, fmt.Errorf("git ls-files --others: %w", err)
+		}
+		out = append(out, untracked...)
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
 	}
-	return strings.TrimSpace(string(out)) != "", nil
+	return files, nil
 }
 
 // showVersion extracts the version field from a Chart.yaml at the given ref.
@@ -110,13 +187,57 @@ func showVersion(repoRoot, ref, relFile string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("git show %s:%s: %w", ref, relFile, err)
 	}
-	for _, line := range strings.Split(string(out), "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "version:") {
-			v := strings.TrimSpace(strings.TrimPrefix(line, "version:"))
-			v = strings.Trim(v, "\"'")
-			return v, nil
+	v, ok := parseVersion(out)
+	if !ok {
+		return "", fmt.Errorf("no version field in %s:%s", ref, relFile)
+	}
+	return v, nil
+}
+
+// parseVersion extracts the top-level version field from Chart.yaml
+// contents, ignoring the version of each dependency and any comment.
+func parseVersion(data []byte) (string, bool) {
+	var doc struct {
+		Version yaml.Node `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Version.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return doc.Version.Value, true
+}
+
+// ShowFile returns the contents of relFile at ref, as git show
+// <ref>:<relFile> prints them; an empty ref reads the index. ok is false
+// when the file does not exist at ref.
+func ShowFile(repoRoot, ref, relFile string) (data []byte, ok bool, err error) {
+	spec := ref + ":" + relFile
+	out, err := exec.Command("git", "-C", repoRoot, "show", spec).Output()
+	if err == nil {
+		return out, true, nil
+	}
+	if (ref == "" || RefExists(repoRoot, ref)) && exec.Command("git", "-C", repoRoot, "cat-file", "-e", spec).Run() != nil {
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("git show %s: %w", spec, err)
+}
+
+// ListFiles lists the files under relDir at ref, relative to repoRoot; an
+// empty ref lists the index. A directory that does not exist at ref has no
+// files.
+func ListFiles(repoRoot, ref, relDir string) ([]string, error) {
+	args := []string{"-C", repoRoot, "ls-tree", "-r", "-z", "--name-only", ref, "--", relDir + "/"}
+	if ref == "" {
+		args = []string{"-C", repoRoot, "ls-files", "-z", "--", relDir + "/"}
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s -- %s: %w", ref, relDir, err)
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
 		}
 	}
-	return "", fmt.Errorf("no version field in %s:%s", ref, relFile)
+	return files, nil
 }
@@ -8,7 +8,7 @@ import (
 )
 
 // initGitRepo creates a fresh git repo in a temp dir and returns its path.
-func initGitRepo(t *testing.T) string {
+func initGitRepo(t testing.TB) string {
 	t.Helper()
 	dir := t.TempDir()
 
@@ -19,7 +19,7 @@ func initGitRepo(t *testing.T) string {
 	return dir
 }
 
-func run(t *testing.T, dir string, name string, args ...string) {
+func run(t testing.TB, dir string, name string, args ...string) {
 	t.Helper()
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
@@ -30,7 +30,7 @@ func run(t *testing.T, dir string, name string, args ...string) {
 	}
 }
 
-func writeFile(t *testing.T, path, content string) {
+func writeFile(t testing.TB, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		t.Fatal(err)
@@ -66,6 +66,32 @@ func TestRefExists(t *testing.T) {
 	}
 }
 
+func TestShowFile(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "charts", "api", "values.yaml"), "a: 1\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "initial")
+
+	data, ok, err := ShowFile(dir, "HEAD", "charts/api/values.yaml")
+	if err != nil || !ok || string(data) != "a: 1\n" {
+		t.Errorf("ShowFile = %q, %v, %v", data, ok, err)
+	}
+	if _, ok, err := ShowFile(dir, "HEAD", "charts/api/values.schema.json"); err != nil || ok {
+		t.Errorf("missing file: ok = %v, err = %v", ok, err)
+	}
+	if _, _, err := ShowFile(dir, "nonexistent-branch", "charts/api/values.yaml"); err == nil {
+		t.Error("expected an error for a missing ref")
+	}
+
+	files, err := ListFiles(dir, "HEAD", "charts")
+	if err != nil || len(files) != 1 || files[0] != "charts/api/values.yaml" {
+		t.Errorf("ListFiles = %v, %v", files, err)
+	}
+	if files, err := ListFiles(dir, "HEAD", "charts/api/crds"); err != nil || len(files) != 0 {
+		t.Errorf("missing dir: ListFiles = %v, %v", files, err)
+	}
+}
+
 func TestIsStaleNoChanges(t *testing.T) {
 	dir := initGitRepo(t)
 
@@ -78,11 +104,11 @@ func TestIsStaleNoChanges(t *testing.T) {
 	run(t, dir, "git", "branch", "base")
 
 	// No changes since base → not stale
-	stale, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if stale {
+	if res.Stale {
 		t.Error("chart with no changes since base should not be stale")
 	}
 }
@@ -103,13 +129,22 @@ func TestIsStaleFileChangedNoVersionBump(t *testing.T) {
 	run(t, dir, "git", "add", "-A")
 	run(t, dir, "git", "commit", "-m", "update values")
 
-	stale, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !stale {
+	if !res.Stale {
 		t.Error("chart with file changes and no version bump should be stale")
 	}
+	if len(res.ChangedFiles) != 1 || res.ChangedFiles[0] != "mychart/values.yaml" {
+		t.Errorf("ChangedFiles = %v", res.ChangedFiles)
+	}
+	if res.BaseVersion != "0.1.0" {
+		t.Errorf("BaseVersion = %q", res.BaseVersion)
+	}
+	if res.MergeBase == "" {
+		t.Error("MergeBase should be set")
+	}
 }
 
 func TestIsStaleVersionBumped(t *testing.T) {
@@ -129,15 +164,58 @@ func TestIsStaleVersionBumped(t *testing.T) {
 	run(t, dir, "git", "add", "-A")
 	run(t, dir, "git", "commit", "-m", "bump version")
 
-	stale, err := IsStale(dir, chartDir, chartFile, "base", "0.2.0")
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.2.0")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if stale {
+	if res.Stale {
 		t.Error("chart with version bump should not be stale")
 	}
 }
 
+func TestParseVersion(t *testing.T) {
+	cases := map[string]string{
+		"apiVersion: v2\nversion: 1.2.0\n":                                  "1.2.0",
+		"version: \"1.2.0\" # released\n":                                   "1.2.0",
+		"version: '1.2.0'\n":                                                "1.2.0",
+		"dependencies:\n  - name: db\n    version: 9.9.9\nversion: 1.2.0\n": "1.2.0",
+	}
+	for in, want := range cases {
+		if got, ok := parseVersion([]byte(in)); !ok || got != want {
+			t.Errorf("parseVersion(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := parseVersion([]byte("dependencies:\n  - name: db\n    version: 9.9.9\n")); ok {
+		t.Error("a dependency version is not the chart version")
+	}
+}
+
+func TestIsStaleDependenciesBeforeVersion(t *testing.T) {
+	dir := initGitRepo(t)
+
+	chartDir := filepath.Join(dir, "mychart")
+	chartFile := filepath.Join(chartDir, "Chart.yaml")
+	deps := "apiVersion: v2\nname: mychart\ndependencies:\n  - name: db\n    version: 9.9.9\n"
+	writeFile(t, chartFile, deps+"version: 0.1.0\n")
+	writeFile(t, filepath.Join(chartDir, "values.yaml"), "key: val\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "initial")
+	run(t, dir, "git", "branch", "base")
+
+	writeFile(t, filepath.Join(chartDir, "values.yaml"), "key: newval\n")
+	writeFile(t, chartFile, deps+"version: 0.2.0\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "bump version")
+
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BaseVersion != "0.1.0" || res.Stale {
+		t.Errorf("expected base version 0.1.0 and not stale, got %+v", res)
+	}
+}
+
 func TestIsStaleNewChart(t *testing.T) {
 	dir := initGitRepo(t)
 
@@ -154,11 +232,11 @@ func TestIsStaleNewChart(t *testing.T) {
 	run(t, dir, "git", "add", "-A")
 	run(t, dir, "git", "commit", "-m", "add chart")
 
-	stale, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.1.0")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if stale {
+	if res.Stale {
 		t.Error("new chart not present in base should not be stale")
 	}
 }
@@ -190,11 +268,11 @@ func TestIsStaleMultipleChangesOneBump(t *testing.T) {
 	run(t, dir, "git", "add", "-A")
 	run(t, dir, "git", "commit", "-m", "bump")
 
-	stale, err := IsStale(dir, chartDir, chartFile, "base", "0.2.0")
+	res, err := IsStale(dir, chartDir, chartFile, "base", "0.2.0")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if stale {
+	if res.Stale {
 		t.Error("chart should not be stale after version bump covers all changes")
 	}
 }
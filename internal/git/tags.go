@@ -0,0 +1,61 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TagPattern describes release tag names, with a {version} placeholder
+// for the chart version and an optional {name} placeholder for the chart
+// name: "{name}-{version}" matches "api-1.2.0", "{name}/v{version}"
+// matches "api/v1.2.0".
+type TagPattern string
+
+// ParseTagPattern checks that p has exactly one {version} placeholder and
+// no placeholders other than {name}.
+func ParseTagPattern(p string) (TagPattern, error) {
+	if strings.Count(p, "{version}") != 1 {
+		return "", fmt.Errorf("tag pattern %q must contain {version} once", p)
+	}
+	rest := strings.NewReplacer("{version}", "", "{name}", "").Replace(p)
+	if strings.ContainsAny(rest, "{}") {
+		return "", fmt.Errorf("tag pattern %q has an unknown placeholder (use {name} and {version})", p)
+	}
+	return TagPattern(p), nil
+}
+
+// Match reports whether tag is a release tag of the chart called name,
+// and returns the version part of it. The version is not validated.
+func (p TagPattern) Match(tag, name string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(strings.ReplaceAll(string(p), "{name}", name), "{version}")
+	if len(tag) <= len(prefix)+len(suffix) || !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) {
+		return "", false
+	}
+	return tag[len(prefix) : len(tag)-len(suffix)], true
+}
+
+// MergedTags lists the tags reachable from HEAD.
+func MergedTags(repoRoot string) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoRoot, "tag", "--list", "--merged", "HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git tag --merged HEAD: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Format returns the tag name for version name of a chart.
+func (p TagPattern) Format(name, version string) string {
+	return strings.NewReplacer("{name}", name, "{version}", version).Replace(string(p))
+}
+
+// TagExists reports whether the tag exists.
+func TagExists(repoRoot, tag string) bool {
+	return RefExists(repoRoot, "refs/tags/"+tag)
+}
+
+// CreateTag creates an annotated tag on HEAD. Lines of message starting
+// with "#", such as Markdown headings, are kept.
+func CreateTag(repoRoot, tag, message string) error {
+	return runGit(repoRoot, strings.NewReader(message), "tag", "-a", "--cleanup=whitespace", "-F", "-", "--", tag)
+}
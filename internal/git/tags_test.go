@@ -0,0 +1,62 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTagPattern(t *testing.T) {
+	for _, p := range []string{"{name}-{version}", "{name}/v{version}", "v{version}"} {
+		if _, err := ParseTagPattern(p); err != nil {
+			t.Errorf("%q: %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "{name}", "{version}-{version}", "{chart}-{version}"} {
+		if _, err := ParseTagPattern(p); err == nil {
+			t.Errorf("%q: expected an error", p)
+		}
+	}
+}
+
+func TestTagPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern, tag, name string
+		version            string
+		ok                 bool
+	}{
+		{"{name}-{version}", "api-1.2.0", "api", "1.2.0", true},
+		{"{name}-{version}", "api-gateway-1.2.0", "api", "gateway-1.2.0", true},
+		{"{name}-{version}", "web-1.2.0", "api", "", false},
+		{"{name}/v{version}", "api/v2.0.0-rc.1", "api", "2.0.0-rc.1", true},
+		{"{name}/v{version}", "api/2.0.0", "api", "", false},
+		{"v{version}", "v1.0.0", "api", "1.0.0", true},
+		{"{name}-{version}", "api-", "api", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := TagPattern(tt.pattern).Match(tt.tag, tt.name)
+		if v != tt.version || ok != tt.ok {
+			t.Errorf("%s.Match(%q, %q) = %q, %v, want %q, %v", tt.pattern, tt.tag, tt.name, v, ok, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestMergedTags(t *testing.T) {
+	dir := initGitRepo(t)
+	writeFile(t, filepath.Join(dir, "a"), "1\n")
+	run(t, dir, "git", "add", "-A")
+	run(t, dir, "git", "commit", "-m", "one")
+	run(t, dir, "git", "tag", "api-1.0.0")
+	run(t, dir, "git", "checkout", "-q", "-b", "side")
+	writeFile(t, filepath.Join(dir, "a"), "2\n")
+	run(t, dir, "git", "commit", "-qam", "two")
+	run(t, dir, "git", "tag", "api-1.1.0")
+	run(t, dir, "git", "checkout", "-q", "-")
+
+	tags, err := MergedTags(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0] != "api-1.0.0" {
+		t.Errorf("got %v, want only the tag reachable from HEAD", tags)
+	}
+}
@@ -0,0 +1,166 @@
+// Package ignore decides which files of a chart directory end up in the
+// packaged chart. It follows the rules of Helm's .helmignore, so a change
+// to a file that helm package would leave out does not make a chart stale.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HelmIgnore is the ignore file Helm reads from a chart directory.
+const HelmIgnore = ".helmignore"
+
+// Rules is an ordered list of ignore patterns. The zero value ignores
+// nothing.
+//
+// Patterns use .helmignore syntax: shell globs as understood by
+// filepath.Match, one per line. A pattern without a slash matches a file
+// or directory name at any depth ("README.md", "*.bak"); a pattern with a
+// slash matches the path relative to the chart directory ("ci/*.yaml"),
+// and a leading slash anchors it there explicitly. A trailing slash
+// matches directories only ("tests/"), and a leading "!" negates the
+// pattern. "**" is not supported, as in Helm.
+type Rules struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	glob    string
+	negate  bool
+	mustDir bool
+	base    bool // match the base name only
+}
+
+// Parse reads patterns from a .helmignore-style file. Blank lines and
+// lines starting with "#" are skipped.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if err := rules.Add(s.Text()); err != nil {
+			return nil, err
+		}
+	}
+	return rules, s.Err()
+}
+
+// ParseFile reads the .helmignore in a chart directory, with Helm's
+// defaults added. A missing file yields just the defaults.
+func ParseFile(dir string) (*Rules, error) {
+	path := filepath.Join(dir, HelmIgnore)
+	rules := &Rules{}
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		defer f.Close()
+		if rules, err = Parse(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := rules.AddDefaults(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// AddDefaults adds the patterns Helm always applies: dotfiles under
+// templates/ are never packaged.
+func (r *Rules) AddDefaults() error {
+	return r.Add("templates/.?*")
+}
+
+// Add appends patterns. Blank patterns and comments are skipped.
+func (r *Rules) Add(patterns ...string) error {
+	for _, raw := range patterns {
+		rule := strings.TrimSpace(raw)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if strings.Contains(rule, "**") {
+			return fmt.Errorf("ignore pattern %q: double-star (**) syntax is not supported", rule)
+		}
+		if _, err := filepath.Match(rule, "abc"); err != nil {
+			return fmt.Errorf("ignore pattern %q: %w", rule, err)
+		}
+
+		p := pattern{}
+		if strings.HasPrefix(rule, "!") {
+			p.negate = true
+			rule = rule[1:]
+		}
+		if strings.HasSuffix(rule, "/") {
+			p.mustDir = true
+			rule = strings.TrimSuffix(rule, "/")
+		}
+		switch {
+		case strings.HasPrefix(rule, "/"):
+			rule = strings.TrimPrefix(rule, "/")
+		case !strings.Contains(rule, "/"):
+			p.base = true
+		}
+		p.glob = rule
+		r.patterns = append(r.patterns, p)
+	}
+	return nil
+}
+
+// Ignored reports whether the file at rel, a slash-separated path relative
+// to the chart directory, is left out of the package, either by a pattern
+// matching the file itself or one matching a directory above it.
+func (r *Rules) Ignored(rel string) bool {
+	if r == nil || len(r.patterns) == 0 {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if r.ignore(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return r.ignore(rel, false)
+}
+
+// ignore applies the patterns to one path the way Helm's ignore.Rules
+// does, including its handling of negated patterns: a negated pattern
+// ignores every path it does not match.
+func (r *Rules) ignore(rel string, isDir bool) bool {
+	if rel == "" || rel == "." {
+		return false
+	}
+	for _, p := range r.patterns {
+		if p.negate {
+			if p.mustDir && !isDir {
+				return true
+			}
+			if !p.match(rel) {
+				return true
+			}
+			continue
+		}
+		if p.mustDir && !isDir {
+			continue
+		}
+		if p.match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pattern) match(rel string) bool {
+	if p.base {
+		rel = path.Base(rel)
+	}
+	ok, _ := path.Match(p.glob, rel)
+	return ok
+}
@@ -0,0 +1,96 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	rules, err := Parse(strings.NewReader(`# Patterns to ignore when building packages.
+.DS_Store
+*.md
+ci/*.yaml
+/tests/
+.vscode/
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"README.md":                   true,
+		"docs/guide.md":               true,
+		"ci/values-test.yaml":         true,
+		"ci/nested/values.yaml":       false,
+		"tests/deployment_test.yaml":  true,
+		"tests":                       false, // a file named tests, not the directory
+		"templates/tests/x.yaml":      false,
+		".vscode/settings.json":       true,
+		"sub/.DS_Store":               true,
+		"values.yaml":                 false,
+		"templates/deployment.yaml":   false,
+		"charts/redis/values.yaml":    false,
+		"charts/redis/ci/values.yaml": false,
+	} {
+		if got := rules.Ignored(rel); got != want {
+			t.Errorf("Ignored(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestIgnored_negate(t *testing.T) {
+	// Helm treats a negated pattern as "ignore everything it does not
+	// match", directories included, and Ignored must agree with helm
+	// package.
+	rules := &Rules{}
+	if err := rules.Add("!*.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"values.yaml":      false,
+		"README.md":        true,
+		"templates/a.yaml": true,
+	} {
+		if got := rules.Ignored(rel); got != want {
+			t.Errorf("Ignored(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestIgnored_zero(t *testing.T) {
+	var rules *Rules
+	if rules.Ignored("README.md") {
+		t.Error("nil rules should ignore nothing")
+	}
+}
+
+func TestAdd_errors(t *testing.T) {
+	for _, p := range []string{"docs/**", "[a-"} {
+		if err := (&Rules{}).Add(p); err == nil {
+			t.Errorf("Add(%q): expected an error", p)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	rules, err := ParseFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.Ignored("templates/.hidden.yaml") || rules.Ignored("README.md") {
+		t.Error("missing .helmignore should yield only the defaults")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, HelmIgnore), []byte("README.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = ParseFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.Ignored("README.md") || !rules.Ignored("templates/.hidden.yaml") {
+		t.Error("expected .helmignore patterns and defaults")
+	}
+}
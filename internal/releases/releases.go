@@ -0,0 +1,100 @@
+// Package releases writes the repository-level release log: one record per
+// helmver apply run listing every chart that was released.
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Formats lists the supported release log formats.
+var Formats = []string{"markdown", "json"}
+
+// Run is one apply run.
+type Run struct {
+	Date     string  `json:"date"`
+	Releases []Chart `json:"releases"`
+}
+
+// Chart is one chart released in a run.
+type Chart struct {
+	Chart         string   `json:"chart"`
+	ID            string   `json:"id"`
+	Bump          string   `json:"bump"`
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	AppVersionOld string   `json:"appVersionFrom,omitempty"`
+	AppVersionNew string   `json:"appVersionTo,omitempty"`
+	Changes       []Change `json:"changes"`
+}
+
+// Change is one reason for a release.
+type Change struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message"`
+}
+
+const markdownHeader = "# Releases\n"
+
+// Render returns the release log with run added as the newest record. A
+// nil existing means the file does not exist yet.
+func Render(existing []byte, run Run, format string) ([]byte, error) {
+	switch format {
+	case "", "markdown":
+		return renderMarkdown(existing, run), nil
+	case "json":
+		return renderJSON(existing, run)
+	}
+	return nil, fmt.Errorf("unknown release log format %q (use %s)", format, strings.Join(Formats, " or "))
+}
+
+func renderMarkdown(existing []byte, run Run) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", run.Date)
+	for _, r := range run.Releases {
+		fmt.Fprintf(&b, "- **%s** %s -> %s (%s)", r.Chart, r.From, r.To, r.Bump)
+		if r.AppVersionNew != "" {
+			from := r.AppVersionOld
+			if from == "" {
+				from = "(none)"
+			}
+			fmt.Fprintf(&b, ", appVersion %s -> %s", from, r.AppVersionNew)
+		}
+		b.WriteString("\n")
+		for _, c := range r.Changes {
+			b.WriteString("  - ")
+			if c.Type != "" {
+				fmt.Fprintf(&b, "%s: ", c.Type)
+			}
+			b.WriteString(strings.ReplaceAll(strings.TrimSpace(c.Message), "\n", "\n    "))
+			b.WriteString("\n")
+		}
+	}
+	entry := b.String()
+
+	content := string(existing)
+	if existing == nil || !strings.HasPrefix(content, "# ") {
+		return []byte(markdownHeader + "\n" + entry + "\n" + content)
+	}
+	idx := strings.Index(content, "\n")
+	if idx < 0 {
+		return []byte(content + "\n\n" + entry)
+	}
+	return []byte(content[:idx+1] + "\n" + entry + "\n" + strings.TrimLeft(content[idx+1:], "\n"))
+}
+
+func renderJSON(existing []byte, run Run) ([]byte, error) {
+	var runs []Run
+	if len(strings.TrimSpace(string(existing))) > 0 {
+		if err := json.Unmarshal(existing, &runs); err != nil {
+			return nil, fmt.Errorf("parsing existing release log: %w", err)
+		}
+	}
+	runs = append([]Run{run}, runs...)
+	b, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,74 @@
+package releases
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var run = Run{
+	Date: "2025-03-02",
+	Releases: []Chart{
+		{
+			Chart: "api", ID: "charts/api", Bump: "minor", From: "1.2.3", To: "1.3.0",
+			AppVersionNew: "2.0.0",
+			Changes:       []Change{{Type: "added", Message: "Add HPA\nwith metrics"}, {Message: "Updated appVersion to 2.0.0"}},
+		},
+		{Chart: "worker", ID: "charts/worker", Bump: "patch", From: "0.5.0", To: "0.5.1", Changes: []Change{}},
+	},
+}
+
+func TestRender_markdown(t *testing.T) {
+	got, err := Render(nil, run, "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Releases\n\n## 2025-03-02\n\n" +
+		"- **api** 1.2.3 -> 1.3.0 (minor), appVersion (none) -> 2.0.0\n" +
+		"  - added: Add HPA\n    with metrics\n" +
+		"  - Updated appVersion to 2.0.0\n" +
+		"- **worker** 0.5.0 -> 0.5.1 (patch)\n\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	older := Run{Date: "2025-01-01", Releases: []Chart{{Chart: "web", Bump: "major", From: "1.0.0", To: "2.0.0"}}}
+	got, err = Render(got, older, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "# Releases\n\n## 2025-01-01\n\n- **web** 1.0.0 -> 2.0.0 (major)\n\n" + want[len("# Releases\n\n"):]
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_json(t *testing.T) {
+	first, err := Render(nil, run, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Render(first, Run{Date: "2025-03-03", Releases: []Chart{}}, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []Run
+	if err := json.Unmarshal(second, &runs); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Date != "2025-03-03" || runs[1].Releases[0].To != "1.3.0" {
+		t.Errorf("unexpected runs: %+v", runs)
+	}
+	if runs[1].Releases[0].Changes[0].Type != "added" {
+		t.Errorf("changes not recorded: %+v", runs[1].Releases[0].Changes)
+	}
+}
+
+func TestRender_errors(t *testing.T) {
+	if _, err := Render(nil, run, "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := Render([]byte("{not json"), run, "json"); err == nil {
+		t.Error("expected an error for a corrupt JSON log")
+	}
+}
@@ -11,10 +11,12 @@ import (
 
 // Changeset captures the user's choices for a single chart.
 type Changeset struct {
-	Chart   *chart.Chart
-	Bump    string
-	NewVer  string
-	Message string
+	Chart      *chart.Chart
+	Bump       string
+	NewVer     string
+	AppVersion string // as entered: explicit value or bump type; empty if unchanged
+	NewAppVer  string // resolved appVersion; empty if unchanged
+	Message    string
 }
 
 // confirmModel shows a summary and asks for y/n confirmation.
@@ -64,6 +66,17 @@ func (m confirmModel) View() string {
 			arrow,
 			okStyle.Render(cs.NewVer),
 		)
+		if cs.NewAppVer != "" {
+			oldApp := cs.Chart.AppVersion
+			if oldApp == "" {
+				oldApp = "none"
+			}
+			fmt.Fprintf(&b, "    appVersion %s %s %s\n",
+				oldApp,
+				arrow,
+				okStyle.Render(cs.NewAppVer),
+			)
+		}
 		// Show first line of message as preview
 		lines := strings.SplitN(cs.Message, "\n", 2)
 		preview := lines[0]
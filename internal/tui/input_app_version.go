@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jordan-simonovski/helmver/internal/chart"
+)
+
+// inputAppVersionModel lets the user optionally change appVersion, either to
+// an explicit value or by a bump type. An empty value leaves it unchanged.
+type inputAppVersionModel struct {
+	chart  *chart.Chart
+	preID  string
+	input  textinput.Model
+	value  string
+	newVer string
+	err    error
+	done   bool
+}
+
+func newInputAppVersionModel(c *chart.Chart, preID string) inputAppVersionModel {
+	ti := textinput.New()
+	ti.Placeholder = "leave empty to keep, or e.g. 2.3.0 / minor"
+	ti.Focus()
+	ti.Width = 48
+	ti.CharLimit = 128
+
+	return inputAppVersionModel{
+		chart: c,
+		preID: preID,
+		input: ti,
+	}
+}
+
+func (m inputAppVersionModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m inputAppVersionModel) Update(msg tea.Msg) (inputAppVersionModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "enter" {
+		val := strings.TrimSpace(m.input.Value())
+		if val == "" {
+			m.done = true
+			return m, nil
+		}
+		newVer, err := chart.ResolveAppVersion(m.chart.AppVersion, val, m.preID)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.value = val
+		m.newVer = newVer
+		m.done = true
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.err = nil
+	return m, cmd
+}
+
+func (m inputAppVersionModel) View() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	hintStyle := lipgloss.NewStyle().Faint(true)
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+
+	current := m.chart.AppVersion
+	if current == "" {
+		current = "none"
+	}
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("appVersion for %s (%s)", m.chart.Name, current)))
+	b.WriteString("\n")
+	b.WriteString(hintStyle.Render("[enter] submit  explicit version or bump type (patch, minor, major, ...)"))
+	b.WriteString("\n\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
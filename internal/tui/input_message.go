@@ -14,12 +14,13 @@ import (
 type inputMessageModel struct {
 	chart    *chart.Chart
 	bump     string
+	preID    string
 	textarea textarea.Model
 	message  string
 	done     bool
 }
 
-func newInputMessageModel(c *chart.Chart, bump string) inputMessageModel {
+func newInputMessageModel(c *chart.Chart, bump, preID string) inputMessageModel {
 	ta := textarea.New()
 	ta.Placeholder = "Describe your changes..."
 	ta.Focus()
@@ -30,6 +31,7 @@ func newInputMessageModel(c *chart.Chart, bump string) inputMessageModel {
 	return inputMessageModel{
 		chart:    c,
 		bump:     bump,
+		preID:    preID,
 		textarea: ta,
 	}
 }
@@ -61,7 +63,7 @@ func (m inputMessageModel) View() string {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
 	hintStyle := lipgloss.NewStyle().Faint(true)
 
-	newVer, _ := chart.BumpVersion(m.chart.Version, m.bump)
+	newVer, _ := chart.BumpVersionPre(m.chart.Version, m.bump, m.preID)
 
 	b.WriteString(titleStyle.Render(fmt.Sprintf("Changelog for %s (%s -> %s)", m.chart.Name, m.chart.Version, newVer)))
 	b.WriteString("\n")
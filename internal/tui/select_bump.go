@@ -9,18 +9,40 @@ import (
 	"github.com/jordan-simonovski/helmver/internal/chart"
 )
 
-var bumpTypes = []string{"patch", "minor", "major"}
+// bumpTypes is the order bump types are offered in. Types that cannot be
+// applied to a chart's current version (e.g. release on a final version)
+// are hidden.
+var bumpTypes = []string{"patch", "minor", "major", "prerelease", "release", "prepatch", "preminor", "premajor"}
+
+var bumpDescriptions = map[string]string{
+	"patch":      "bug fixes, no API changes",
+	"minor":      "new features, backwards compatible",
+	"major":      "breaking changes",
+	"prerelease": "next pre-release",
+	"release":    "drop the pre-release suffix",
+	"prepatch":   "pre-release of the next patch",
+	"preminor":   "pre-release of the next minor",
+	"premajor":   "pre-release of the next major",
+}
 
 // selectBumpModel lets the user pick a semver bump type for a single chart.
 type selectBumpModel struct {
 	chart    *chart.Chart
+	preID    string
+	options  []string
 	cursor   int
 	selected string
 	done     bool
 }
 
-func newSelectBumpModel(c *chart.Chart) selectBumpModel {
-	return selectBumpModel{chart: c}
+func newSelectBumpModel(c *chart.Chart, preID string) selectBumpModel {
+	var options []string
+	for _, bt := range bumpTypes {
+		if _, err := chart.BumpVersionPre(c.Version, bt, preID); err == nil {
+			options = append(options, bt)
+		}
+	}
+	return selectBumpModel{chart: c, preID: preID, options: options}
 }
 
 func (m selectBumpModel) Init() tea.Cmd {
@@ -35,12 +57,14 @@ func (m selectBumpModel) Update(msg tea.Msg) (selectBumpModel, tea.Cmd) {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(bumpTypes)-1 {
+			if m.cursor < len(m.options)-1 {
 				m.cursor++
 			}
 		case "enter":
-			m.selected = bumpTypes[m.cursor]
-			m.done = true
+			if len(m.options) > 0 {
+				m.selected = m.options[m.cursor]
+				m.done = true
+			}
 		}
 	}
 	return m, nil
@@ -59,20 +83,19 @@ func (m selectBumpModel) View() string {
 	b.WriteString(hintStyle.Render("[up/down] navigate  [enter] select"))
 	b.WriteString("\n\n")
 
-	descriptions := map[string]string{
-		"patch": "bug fixes, no API changes",
-		"minor": "new features, backwards compatible",
-		"major": "breaking changes",
+	if len(m.options) == 0 {
+		b.WriteString(descStyle.Render(fmt.Sprintf("  version %q is not valid semver", m.chart.Version)))
+		b.WriteString("\n")
 	}
 
-	for i, bt := range bumpTypes {
+	for i, bt := range m.options {
 		cursor := "  "
 		if i == m.cursor {
 			cursor = cursorStyle.Render("> ")
 		}
 
-		preview, _ := chart.BumpVersion(m.chart.Version, bt)
-		desc := descStyle.Render(fmt.Sprintf("- %s (%s -> %s)", descriptions[bt], m.chart.Version, preview))
+		preview, _ := chart.BumpVersionPre(m.chart.Version, bt, m.preID)
+		desc := descStyle.Render(fmt.Sprintf("- %s (%s -> %s)", bumpDescriptions[bt], m.chart.Version, preview))
 
 		label := bt
 		if i == m.cursor {
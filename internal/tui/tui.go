@@ -13,6 +13,7 @@ type phase int
 const (
 	phaseSelectCharts phase = iota
 	phaseSelectBump
+	phaseInputAppVersion
 	phaseInputMessage
 	phaseConfirm
 	phaseDone
@@ -21,12 +22,14 @@ const (
 // Model is the top-level bubbletea model that orchestrates all phases.
 type Model struct {
 	phase phase
+	preID string // pre-release identifier for pre* bumps
 
 	// Sub-models
-	selectCharts selectChartsModel
-	selectBump   selectBumpModel
-	inputMessage inputMessageModel
-	confirm      confirmModel
+	selectCharts    selectChartsModel
+	selectBump      selectBumpModel
+	inputAppVersion inputAppVersionModel
+	inputMessage    inputMessageModel
+	confirm         confirmModel
 
 	// Accumulated state
 	allCharts      []*chart.Chart
@@ -42,9 +45,11 @@ type Model struct {
 
 // New creates the top-level TUI model with all discovered charts.
 // Charts with Stale=true are highlighted; others are dimmed but still selectable.
-func New(charts []*chart.Chart) Model {
+// preID is the pre-release identifier used for premajor/preminor/prepatch bumps.
+func New(charts []*chart.Chart, preID string) Model {
 	return Model{
 		phase:        phaseSelectCharts,
+		preID:        preID,
 		allCharts:    charts,
 		selectCharts: newSelectChartsModel(charts),
 	}
@@ -62,8 +67,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Aborted = true
 			return m, tea.Quit
 		case "q":
-			// Only quit on q if we're not in the text input phase
-			if m.phase != phaseInputMessage {
+			// Only quit on q if we're not in a text input phase
+			if m.phase != phaseInputMessage && m.phase != phaseInputAppVersion {
 				m.Aborted = true
 				return m, tea.Quit
 			}
@@ -75,6 +80,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateSelectCharts(msg)
 	case phaseSelectBump:
 		return m.updateSelectBump(msg)
+	case phaseInputAppVersion:
+		return m.updateInputAppVersion(msg)
 	case phaseInputMessage:
 		return m.updateInputMessage(msg)
 	case phaseConfirm:
@@ -93,7 +100,7 @@ func (m Model) updateSelectCharts(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.changesets = nil
 		m.currentIdx = 0
 		m.phase = phaseSelectBump
-		m.selectBump = newSelectBumpModel(m.selectedCharts[0])
+		m.selectBump = newSelectBumpModel(m.selectedCharts[0], m.preID)
 	}
 
 	return m, cmd
@@ -105,9 +112,22 @@ func (m Model) updateSelectBump(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	if m.selectBump.done {
 		c := m.selectedCharts[m.currentIdx]
-		bump := m.selectBump.selected
+		m.phase = phaseInputAppVersion
+		m.inputAppVersion = newInputAppVersionModel(c, m.preID)
+		return m, m.inputAppVersion.Init()
+	}
+
+	return m, cmd
+}
+
+func (m Model) updateInputAppVersion(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.inputAppVersion, cmd = m.inputAppVersion.Update(msg)
+
+	if m.inputAppVersion.done {
+		c := m.selectedCharts[m.currentIdx]
 		m.phase = phaseInputMessage
-		m.inputMessage = newInputMessageModel(c, bump)
+		m.inputMessage = newInputMessageModel(c, m.selectBump.selected, m.preID)
 		return m, m.inputMessage.Init()
 	}
 
@@ -121,24 +141,26 @@ func (m Model) updateInputMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.inputMessage.done {
 		c := m.selectedCharts[m.currentIdx]
 		bump := m.inputMessage.bump
-		newVer, err := chart.BumpVersion(c.Version, bump)
+		newVer, err := chart.BumpVersionPre(c.Version, bump, m.preID)
 		if err != nil {
 			m.Err = err
 			return m, tea.Quit
 		}
 
 		m.changesets = append(m.changesets, Changeset{
-			Chart:   c,
-			Bump:    bump,
-			NewVer:  newVer,
-			Message: m.inputMessage.message,
+			Chart:      c,
+			Bump:       bump,
+			NewVer:     newVer,
+			AppVersion: m.inputAppVersion.value,
+			NewAppVer:  m.inputAppVersion.newVer,
+			Message:    m.inputMessage.message,
 		})
 
 		m.currentIdx++
 		if m.currentIdx < len(m.selectedCharts) {
 			// More charts to process
 			m.phase = phaseSelectBump
-			m.selectBump = newSelectBumpModel(m.selectedCharts[m.currentIdx])
+			m.selectBump = newSelectBumpModel(m.selectedCharts[m.currentIdx], m.preID)
 		} else {
 			// All done, show confirmation
 			m.phase = phaseConfirm
@@ -171,6 +193,8 @@ func (m Model) View() string {
 		return m.selectCharts.View()
 	case phaseSelectBump:
 		return m.selectBump.View()
+	case phaseInputAppVersion:
+		return m.inputAppVersion.View()
 	case phaseInputMessage:
 		return m.inputMessage.View()
 	case phaseConfirm:
@@ -188,8 +212,8 @@ func (m Model) Changesets() []Changeset {
 
 // Run starts the TUI and returns the result.
 // Pass all discovered charts; staleness is indicated by each chart's Stale field.
-func Run(charts []*chart.Chart) ([]Changeset, error) {
-	m := New(charts)
+func Run(charts []*chart.Chart, preID string) ([]Changeset, error) {
+	m := New(charts, preID)
 	p := tea.NewProgram(m)
 	result, err := p.Run()
 	if err != nil {
@@ -0,0 +1,421 @@
+// Package values compares two versions of a chart's values.yaml and
+// values.schema.json, and classifies the differences by the version bump
+// they call for: anything that can break a user's value overrides needs a
+// major bump, a new key needs a minor bump.
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File and SchemaFile are the values files Helm reads from a chart
+// directory.
+const (
+	File       = "values.yaml"
+	SchemaFile = "values.schema.json"
+)
+
+// Kinds of change.
+const (
+	Removed     = "removed"      // a key or schema property was removed
+	Added       = "added"        // a key or schema property was added
+	TypeChanged = "type-changed" // a value or property no longer accepts its old type
+	Required    = "required"     // a schema property became required
+	Tightened   = "tightened"    // a schema property's validation became stricter
+)
+
+// Change is one difference between two versions of a chart's values.
+type Change struct {
+	Path   string // dotted key path, e.g. "image.tag"; "[]" marks list items
+	Kind   string
+	Schema bool   // the change is in values.schema.json
+	Detail string // old and new type, for TypeChanged and Tightened
+}
+
+// Breaking reports whether the change can break existing value overrides.
+func (c Change) Breaking() bool {
+	return c.Kind != Added
+}
+
+func (c Change) String() string {
+	what := "key"
+	if c.Schema {
+		what = "schema property"
+	}
+	switch c.Kind {
+	case TypeChanged:
+		return fmt.Sprintf("changed type of %s %s (%s)", what, c.Path, c.Detail)
+	case Required:
+		return fmt.Sprintf("made schema property %s required", c.Path)
+	case Tightened:
+		return fmt.Sprintf("tightened validation of schema property %s (%s)", c.Path, c.Detail)
+	default:
+		return fmt.Sprintf("%s %s %s", c.Kind, what, c.Path)
+	}
+}
+
+// Bump returns the bump the changes require: "major" if any is breaking,
+// "minor" if any adds a key, and "" otherwise.
+func Bump(changes []Change) string {
+	bump := ""
+	for _, c := range changes {
+		if c.Breaking() {
+			return "major"
+		}
+		bump = "minor"
+	}
+	return bump
+}
+
+// Diff compares two versions of values.yaml. A nil side stands for a
+// missing file. Keys are compared recursively through maps; lists and
+// scalars are compared by type only, and null matches any type since it
+// usually marks a value users are expected to set. Changed defaults are
+// not reported.
+func Diff(base, head []byte) ([]Change, error) {
+	var b, h any
+	if err := yaml.Unmarshal(base, &b); err != nil {
+		return nil, fmt.Errorf("parsing base %s: %w", File, err)
+	}
+	if err := yaml.Unmarshal(head, &h); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", File, err)
+	}
+	var changes []Change
+	diffValues("", normalize(b), normalize(h), &changes)
+	return changes, nil
+}
+
+func diffValues(path string, base, head any, out *[]Change) {
+	bm, bok := base.(map[string]any)
+	hm, hok := head.(map[string]any)
+	if path == "" {
+		// An empty document is an empty map.
+		if base == nil {
+			bm, bok = map[string]any{}, true
+		}
+		if head == nil {
+			hm, hok = map[string]any{}, true
+		}
+	}
+	if bok && hok {
+		for _, k := range sortedKeys(bm) {
+			if hv, ok := hm[k]; ok {
+				diffValues(join(path, k), bm[k], hv, out)
+			} else {
+				*out = append(*out, Change{Path: join(path, k), Kind: Removed})
+			}
+		}
+		for _, k := range sortedKeys(hm) {
+			if _, ok := bm[k]; !ok {
+				*out = append(*out, Change{Path: join(path, k), Kind: Added})
+			}
+		}
+		return
+	}
+
+	bt, ht := typeOf(base), typeOf(head)
+	if bt != ht && bt != "null" && ht != "null" {
+		*out = append(*out, Change{Path: path, Kind: TypeChanged, Detail: bt + " -> " + ht})
+	}
+}
+
+// DiffSchema compares two versions of values.schema.json. A nil side
+// stands for a missing file, which accepts anything. See CompareSchema.
+func DiffSchema(base, head []byte) ([]Change, error) {
+	b, err := parseSchema(base)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base %s: %w", SchemaFile, err)
+	}
+	h, err := parseSchema(head)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", SchemaFile, err)
+	}
+	return CompareSchema(b, h), nil
+}
+
+// CompareSchema compares two decoded JSON schemas. It walks properties and
+// list items, and reports removed and added properties, types that no
+// longer accept what they used to (string -> integer, number -> integer),
+// properties that became required, and stricter validation: enum values
+// removed, bounds such as minimum or maxLength narrowed, and patterns or
+// x-kubernetes-validations rules added.
+func CompareSchema(base, head map[string]any) []Change {
+	var changes []Change
+	diffSchema("", normalize(base).(map[string]any), normalize(head).(map[string]any), &changes)
+	for i := range changes {
+		changes[i].Schema = true
+	}
+	return changes
+}
+
+func parseSchema(data []byte) (map[string]any, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return map[string]any{}, nil
+	}
+	var s map[string]any
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func diffSchema(path string, base, head map[string]any, out *[]Change) {
+	bt, ht := schemaTypes(base), schemaTypes(head)
+	if !acceptsAll(ht, bt) {
+		*out = append(*out, Change{Path: path, Kind: TypeChanged, Detail: typeList(bt) + " -> " + typeList(ht)})
+	}
+
+	for _, detail := range tightened(base, head) {
+		*out = append(*out, Change{Path: path, Kind: Tightened, Detail: detail})
+	}
+
+	required := make(map[string]bool)
+	for _, r := range stringList(base["required"]) {
+		required[r] = true
+	}
+	for _, r := range stringList(head["required"]) {
+		if !required[r] {
+			*out = append(*out, Change{Path: join(path, r), Kind: Required})
+		}
+	}
+
+	bp, hp := subschemas(base["properties"]), subschemas(head["properties"])
+	for _, k := range sortedKeys(bp) {
+		if hs, ok := hp[k]; ok {
+			diffSchema(join(path, k), bp[k], hs, out)
+		} else {
+			*out = append(*out, Change{Path: join(path, k), Kind: Removed})
+		}
+	}
+	for _, k := range sortedKeys(hp) {
+		if _, ok := bp[k]; !ok {
+			*out = append(*out, Change{Path: join(path, k), Kind: Added})
+		}
+	}
+
+	if bi, ok := base["items"].(map[string]any); ok {
+		if hi, ok := head["items"].(map[string]any); ok {
+			diffSchema(path+"[]", bi, hi, out)
+		}
+	}
+}
+
+// Bounds that reject more values when raised, and when lowered.
+var (
+	lowerBounds = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	upperBounds = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+)
+
+// tightened describes how head validates a value more strictly than base,
+// not counting its type and properties.
+func tightened(base, head map[string]any) []string {
+	var out []string
+	if he, ok := head["enum"].([]any); ok {
+		be, ok := base["enum"].([]any)
+		if !ok {
+			out = append(out, "enum added")
+		}
+		for _, v := range be {
+			if !containsValue(he, v) {
+				out = append(out, fmt.Sprintf("enum value %v removed", v))
+			}
+		}
+	}
+	for _, k := range lowerBounds {
+		if h, ok := number(head[k]); ok {
+			if b, ok := number(base[k]); !ok || h > b {
+				out = append(out, boundDetail(k, base[k], head[k]))
+			}
+		}
+	}
+	for _, k := range upperBounds {
+		if h, ok := number(head[k]); ok {
+			if b, ok := number(base[k]); !ok || h < b {
+				out = append(out, boundDetail(k, base[k], head[k]))
+			}
+		}
+	}
+	if hp, ok := head["pattern"].(string); ok && hp != base["pattern"] {
+		out = append(out, "pattern "+hp)
+	}
+	if base["additionalProperties"] != false && head["additionalProperties"] == false {
+		out = append(out, "additionalProperties false")
+	}
+	rules := make(map[any]bool)
+	for _, r := range listOf(base["x-kubernetes-validations"]) {
+		rules[ruleOf(r)] = true
+	}
+	for _, r := range listOf(head["x-kubernetes-validations"]) {
+		if !rules[ruleOf(r)] {
+			out = append(out, fmt.Sprintf("rule %v", ruleOf(r)))
+		}
+	}
+	return out
+}
+
+func boundDetail(key string, base, head any) string {
+	if base == nil {
+		return fmt.Sprintf("%s %v", key, head)
+	}
+	return fmt.Sprintf("%s %v -> %v", key, base, head)
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func containsValue(list []any, v any) bool {
+	for _, x := range list {
+		if fmt.Sprint(x) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func listOf(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
+
+// ruleOf returns the CEL expression of an x-kubernetes-validations entry.
+func ruleOf(v any) any {
+	if m, ok := v.(map[string]any); ok {
+		return m["rule"]
+	}
+	return v
+}
+
+// schemaTypes returns the "type" keyword as a list; nil means any type.
+func schemaTypes(s map[string]any) []string {
+	if t, ok := s["type"].(string); ok {
+		return []string{t}
+	}
+	return stringList(s["type"])
+}
+
+// acceptsAll reports whether a schema of types ht accepts every value of
+// types bt.
+func acceptsAll(ht, bt []string) bool {
+	if len(ht) == 0 {
+		return true
+	}
+	if len(bt) == 0 {
+		return false
+	}
+	for _, t := range bt {
+		if !accepts(ht, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func accepts(types []string, t string) bool {
+	for _, x := range types {
+		if x == t || (x == "number" && t == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeList(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
+
+func subschemas(v any) map[string]map[string]any {
+	m, _ := v.(map[string]any)
+	out := make(map[string]map[string]any, len(m))
+	for k, s := range m {
+		// A boolean schema (true/false) has no properties to compare.
+		sm, _ := s.(map[string]any)
+		if sm == nil {
+			sm = map[string]any{}
+		}
+		out[k] = sm
+	}
+	return out
+}
+
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var out []string
+	for _, x := range list {
+		if s, ok := x.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// normalize converts maps with non-string keys, which yaml.v3 produces for
+// keys such as 1 or true, to string-keyed maps.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			v[k] = normalize(x)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[fmt.Sprint(k)] = normalize(x)
+		}
+		return m
+	}
+	return v
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int, int64, uint64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package values
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := []byte(`
+replicaCount: 1
+image:
+  repository: nginx
+  tag: "1.25"
+  pullPolicy: IfNotPresent
+service:
+  port: 80
+resources: {}
+tolerations: []
+nodeSelector:
+`)
+	head := []byte(`
+replicaCount: "2"
+image:
+  repository: nginx
+  digest: ""
+service:
+  port: 8080.5
+resources:
+  limits:
+    cpu: 100m
+tolerations: {}
+nodeSelector:
+  disk: ssd
+ingress:
+  enabled: false
+`)
+	got, err := Diff(base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Path: "image.pullPolicy", Kind: Removed},
+		{Path: "image.tag", Kind: Removed},
+		{Path: "image.digest", Kind: Added},
+		{Path: "replicaCount", Kind: TypeChanged, Detail: "number -> string"},
+		{Path: "resources.limits", Kind: Added},
+		{Path: "tolerations", Kind: TypeChanged, Detail: "list -> map"},
+		{Path: "ingress", Kind: Added},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+	if Bump(got) != "major" {
+		t.Errorf("Bump = %q, want major", Bump(got))
+	}
+}
+
+func TestDiff_missingFiles(t *testing.T) {
+	got, err := Diff(nil, []byte("a: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Kind != Added || Bump(got) != "minor" {
+		t.Errorf("new file: got %+v", got)
+	}
+
+	got, err = Diff([]byte("a: 1\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Kind != Removed {
+		t.Errorf("deleted file: got %+v", got)
+	}
+
+	if _, err := Diff(nil, []byte("a: [\n")); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestDiff_unchangedKeys(t *testing.T) {
+	got, err := Diff([]byte("a:\n  b: 1\nc: x\n"), []byte("a:\n  b: 2\nc: y\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 || Bump(got) != "" {
+		t.Errorf("changed defaults should not be reported, got %+v", got)
+	}
+}
+
+func TestDiffSchema(t *testing.T) {
+	base := []byte(`{
+  "type": "object",
+  "required": ["image"],
+  "properties": {
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": "string"},
+        "pullPolicy": {"type": "string"}
+      }
+    },
+    "replicaCount": {"type": "number"},
+    "port": {"type": "integer"},
+    "labels": {"type": "array", "items": {"type": ["string", "null"]}}
+  }
+}`)
+	head := []byte(`{
+  "type": "object",
+  "required": ["image", "port"],
+  "properties": {
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": ["string", "integer"]}
+      }
+    },
+    "replicaCount": {"type": "integer"},
+    "port": {"type": "number"},
+    "labels": {"type": "array", "items": {"type": "string"}},
+    "ingress": {"type": "object"}
+  }
+}`)
+	got, err := DiffSchema(base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Path: "port", Kind: Required, Schema: true},
+		{Path: "image.pullPolicy", Kind: Removed, Schema: true},
+		{Path: "labels[]", Kind: TypeChanged, Schema: true, Detail: "string|null -> string"},
+		{Path: "replicaCount", Kind: TypeChanged, Schema: true, Detail: "number -> integer"},
+		{Path: "ingress", Kind: Added, Schema: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCompareSchema_tightened(t *testing.T) {
+	base := map[string]any{"properties": map[string]any{
+		"mode":  map[string]any{"type": "string", "enum": []any{"a", "b"}},
+		"size":  map[string]any{"type": "integer", "maximum": 10, "minimum": 1},
+		"name":  map[string]any{"type": "string"},
+		"label": map[string]any{"type": "string", "maxLength": 5},
+	}}
+	head := map[string]any{"properties": map[string]any{
+		"mode":  map[string]any{"type": "string", "enum": []any{"a", "c"}},
+		"size":  map[string]any{"type": "integer", "maximum": 5, "minimum": 0},
+		"name":  map[string]any{"type": "string", "pattern": "^[a-z]+$"},
+		"label": map[string]any{"type": "string", "maxLength": 8.0},
+	}}
+	got := CompareSchema(base, head)
+	want := []Change{
+		{Path: "mode", Kind: Tightened, Schema: true, Detail: "enum value b removed"},
+		{Path: "name", Kind: Tightened, Schema: true, Detail: "pattern ^[a-z]+$"},
+		{Path: "size", Kind: Tightened, Schema: true, Detail: "maximum 10 -> 5"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffSchema_newSchema(t *testing.T) {
+	got, err := DiffSchema(nil, []byte(`{"type": "object", "properties": {"a": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A schema where there was none restricts what values are accepted.
+	if Bump(got) != "major" {
+		t.Errorf("got %+v, want a breaking change", got)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := []struct {
+		c    Change
+		want string
+	}{
+		{Change{Path: "image.tag", Kind: Removed}, "removed key image.tag"},
+		{Change{Path: "ingress", Kind: Added, Schema: true}, "added schema property ingress"},
+		{Change{Path: "replicas", Kind: TypeChanged, Detail: "number -> string"}, "changed type of key replicas (number -> string)"},
+		{Change{Path: "port", Kind: Required, Schema: true}, "made schema property port required"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
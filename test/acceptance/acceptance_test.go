@@ -91,9 +91,16 @@ func gitRun(t *testing.T, dir string, args ...string) {
 }
 
 func helmver(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+	return helmverEnv(t, dir, nil, args...)
+}
+
+// helmverEnv runs the binary with extra environment variables ("KEY=value").
+func helmverEnv(t *testing.T, dir string, env []string, args ...string) (string, int) {
 	t.Helper()
 	cmd := exec.Command(binary, args...)
 	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
 	out, err := cmd.CombinedOutput()
 	exitCode := 0
 	if err != nil {
@@ -243,7 +250,8 @@ func TestAcceptance_SingleChart_CleanAfterBumpCommit(t *testing.T) {
 	repo := setupFixture(t, "single-chart")
 
 	// Edit values, commit
-	writeFile(t, filepath.Join(repo, "values.yaml"), "replicaCount: 10\n")
+	values := filepath.Join(repo, "values.yaml")
+	writeFile(t, values, strings.Replace(readFile(t, values), "replicaCount: 1", "replicaCount: 10", 1))
 	gitRun(t, repo, "add", "-A")
 	gitRun(t, repo, "commit", "-m", "change values")
 
@@ -339,6 +347,7 @@ func TestAcceptance_Monorepo_BumpOneLeaveOthersClean(t *testing.T) {
 
 	// Bump only api
 	c, _ := chart.Load(filepath.Join(repo, "charts", "api", "Chart.yaml"))
+	oldVer := c.Version
 	newVer, _ := chart.BumpVersion(c.Version, "minor")
 	if err := c.SetVersion(newVer); err != nil {
 		t.Fatal(err)
@@ -357,9 +366,13 @@ func TestAcceptance_Monorepo_BumpOneLeaveOthersClean(t *testing.T) {
 	if !strings.Contains(out, "2 chart(s)") {
 		t.Errorf("expected 2 stale charts, got:\n%s", out)
 	}
-	if strings.Contains(out, "api") {
+	_, staleList, _ := strings.Cut(out, "need a version bump")
+	if strings.Contains(staleList, "api") {
 		t.Errorf("api should not be stale after bump:\n%s", out)
 	}
+	if !strings.Contains(out, oldVer+" -> "+newVer+"  (minor)") {
+		t.Errorf("expected the inferred minor bump for api, got:\n%s", out)
+	}
 }
 
 func TestAcceptance_Monorepo_ApplyMultipleBumps(t *testing.T) {
@@ -586,6 +599,36 @@ func TestAcceptance_Subchart_BumpSubchartOnly(t *testing.T) {
 	}
 }
 
+func TestAcceptance_Subchart_ApplyCascadesToParent(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(testdataDir(), "subchart-parent"), dir)
+	writeFile(t, filepath.Join(dir, ".helmver", "redis.md"),
+		"---\n\"redis\": minor\n---\n\nAdded persistence support.\n")
+
+	out, code := helmver(t, dir, "apply")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "redis: 0.1.0 -> 0.2.0 (minor)") {
+		t.Errorf("expected redis bump, got:\n%s", out)
+	}
+	if !strings.Contains(out, "parent-app: 3.0.0 -> 3.0.1 (patch)") {
+		t.Errorf("expected cascaded parent bump, got:\n%s", out)
+	}
+	if !strings.Contains(out, "2 chart(s) updated") {
+		t.Errorf("expected 2 charts updated, got:\n%s", out)
+	}
+
+	parent := readFile(t, filepath.Join(dir, "Chart.yaml"))
+	if !strings.Contains(parent, `version: "0.2.0"`) {
+		t.Errorf("dependency pin not updated:\n%s", parent)
+	}
+	cl := readFile(t, filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(cl, "Updated dependency redis to 0.2.0") {
+		t.Errorf("parent changelog should mention the dependency:\n%s", cl)
+	}
+}
+
 // ===================================================================
 // Non-git acceptance tests (changeset path)
 // ===================================================================
@@ -702,12 +745,11 @@ func TestAcceptance_Binary_CheckSubchart(t *testing.T) {
 func TestAcceptance_Apply_SingleChart(t *testing.T) {
 	dir := t.TempDir()
 	copyDir(t, filepath.Join(testdataDir(), "single-chart"), dir)
-	today := time.Now().Format("2006-01-02")
 
 	writeFile(t, filepath.Join(dir, ".helmver", "bump.md"),
 		"---\n\"myapp\": minor\n---\n\nAdded readiness probe configuration\n")
 
-	out, code := helmver(t, dir, "apply")
+	out, code := helmver(t, dir, "apply", "--date", "2025-03-01")
 	if code != 0 {
 		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
 	}
@@ -727,11 +769,8 @@ func TestAcceptance_Apply_SingleChart(t *testing.T) {
 	}
 
 	cl := readFile(t, filepath.Join(dir, "CHANGELOG.md"))
-	if !strings.Contains(cl, "## 0.2.0 ("+today+")") {
-		t.Errorf("changelog missing entry:\n%s", cl)
-	}
-	if !strings.Contains(cl, "readiness probe") {
-		t.Errorf("changelog missing message:\n%s", cl)
+	if want := "# Changelog\n\n## 0.2.0 (2025-03-01)\n\nAdded readiness probe configuration\n\n"; cl != want {
+		t.Errorf("changelog mismatch:\ngot:\n%s\nwant:\n%s", cl, want)
 	}
 
 	// Changeset consumed
@@ -743,7 +782,6 @@ func TestAcceptance_Apply_SingleChart(t *testing.T) {
 func TestAcceptance_Apply_Monorepo_MultipleChangesets(t *testing.T) {
 	dir := t.TempDir()
 	copyDir(t, filepath.Join(testdataDir(), "monorepo"), dir)
-	today := time.Now().Format("2006-01-02")
 
 	// Two changesets for api (patch + minor -> minor wins), one for worker
 	writeFile(t, filepath.Join(dir, ".helmver", "aaa.md"),
@@ -753,7 +791,9 @@ func TestAcceptance_Apply_Monorepo_MultipleChangesets(t *testing.T) {
 	writeFile(t, filepath.Join(dir, ".helmver", "ccc.md"),
 		"---\n\"worker\": patch\n---\n\nFixed retry logic\n")
 
-	out, code := helmver(t, dir, "apply", "--dir", filepath.Join(dir, "charts"))
+	// 2025-03-02T00:00:00Z
+	env := []string{"SOURCE_DATE_EPOCH=1740873600"}
+	out, code := helmverEnv(t, dir, env, "apply", "--dir", filepath.Join(dir, "charts"))
 	if code != 0 {
 		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
 	}
@@ -770,11 +810,8 @@ func TestAcceptance_Apply_Monorepo_MultipleChangesets(t *testing.T) {
 		t.Errorf("api expected 1.3.0, got %q", apiChart.Version)
 	}
 	apiCL := readFile(t, filepath.Join(dir, "charts", "api", "CHANGELOG.md"))
-	if !strings.Contains(apiCL, "## 1.3.0 ("+today+")") {
-		t.Errorf("api changelog missing heading:\n%s", apiCL)
-	}
-	if !strings.Contains(apiCL, "Fixed null check") || !strings.Contains(apiCL, "Added pagination") {
-		t.Errorf("api changelog missing messages:\n%s", apiCL)
+	if want := "# Changelog\n\n## 1.3.0 (2025-03-02)\n\nFixed null check\n\nAdded pagination\n\n"; apiCL != want {
+		t.Errorf("api changelog mismatch:\ngot:\n%s\nwant:\n%s", apiCL, want)
 	}
 
 	// worker: 0.5.0 -> 0.5.1
@@ -782,6 +819,10 @@ func TestAcceptance_Apply_Monorepo_MultipleChangesets(t *testing.T) {
 	if workerChart.Version != "0.5.1" {
 		t.Errorf("worker expected 0.5.1, got %q", workerChart.Version)
 	}
+	workerCL := readFile(t, filepath.Join(dir, "charts", "worker", "CHANGELOG.md"))
+	if want := "# Changelog\n\n## 0.5.1 (2025-03-02)\n\nFixed retry logic\n\n"; workerCL != want {
+		t.Errorf("worker changelog mismatch:\ngot:\n%s\nwant:\n%s", workerCL, want)
+	}
 
 	// web: untouched
 	webChart, _ := chart.Load(filepath.Join(dir, "charts", "web", "Chart.yaml"))
@@ -797,7 +838,7 @@ func TestAcceptance_Apply_ComplexChart_PreservesComments(t *testing.T) {
 	writeFile(t, filepath.Join(dir, ".helmver", "bump.md"),
 		"---\n\"complex-app\": major\n---\n\nBreaking schema changes\n")
 
-	out, code := helmver(t, dir, "apply")
+	out, code := helmver(t, dir, "apply", "--date", "2025-07-01")
 	if code != 0 {
 		t.Fatalf("expected exit 0, got %d. output:\n%s", code, out)
 	}
@@ -818,10 +859,12 @@ func TestAcceptance_Apply_ComplexChart_PreservesComments(t *testing.T) {
 		t.Errorf("expected v2.0.0, got %q", c.Version)
 	}
 
-	// Existing changelog entries should be preserved
+	// The new entry goes on top and existing entries are preserved
+	existing := readFile(t, filepath.Join(testdataDir(), "complex-chart", "CHANGELOG.md"))
 	cl := readFile(t, filepath.Join(dir, "CHANGELOG.md"))
-	if !strings.Contains(cl, "v1.5.2") || !strings.Contains(cl, "v1.5.1") {
-		t.Errorf("old changelog entries lost:\n%s", cl)
+	want := strings.Replace(existing, "# Changelog\n", "# Changelog\n\n## v2.0.0 (2025-07-01)\n\nBreaking schema changes\n\n", 1)
+	if cl != want {
+		t.Errorf("changelog mismatch:\ngot:\n%s\nwant:\n%s", cl, want)
 	}
 }
 
@@ -894,7 +937,8 @@ func TestAcceptance_Check_RequireChangeset_StaleWithChangeset(t *testing.T) {
 	repo := setupFixture(t, "single-chart")
 
 	// Make chart stale
-	writeFile(t, filepath.Join(repo, "values.yaml"), "replicaCount: 99\n")
+	values := filepath.Join(repo, "values.yaml")
+	writeFile(t, values, strings.Replace(readFile(t, values), "replicaCount: 1", "replicaCount: 99", 1))
 	gitRun(t, repo, "add", "-A")
 	gitRun(t, repo, "commit", "-m", "change values")
 
@@ -967,7 +1011,8 @@ func TestAcceptance_FullWorkflow_WriteApplyCheck(t *testing.T) {
 	repo := setupFixture(t, "monorepo")
 
 	// Make api stale
-	writeFile(t, filepath.Join(repo, "charts", "api", "values.yaml"), "replicas: 5\n")
+	values := filepath.Join(repo, "charts", "api", "values.yaml")
+	writeFile(t, values, strings.Replace(readFile(t, values), "replicaCount: 2", "replicaCount: 5", 1))
 	gitRun(t, repo, "add", "-A")
 	gitRun(t, repo, "commit", "-m", "scale api")
 
@@ -1007,3 +1052,52 @@ func TestAcceptance_FullWorkflow_WriteApplyCheck(t *testing.T) {
 		t.Errorf("should be clean after apply+commit, got %d:\n%s", code, out)
 	}
 }
+
+func TestAcceptance_FullWorkflow_ApplyCommitTag(t *testing.T) {
+	repo := setupFixture(t, "monorepo")
+	chartsDir := filepath.Join(repo, "charts")
+
+	writeFile(t, filepath.Join(repo, ".helmver", "config.yaml"),
+		"staleness:\n  tagPattern: \"{name}/v{version}\"\ncommit:\n  message: |\n    chore: release{{range .Releases}} {{.Label}}@{{.NewVersion}}{{end}} [skip ci]\n")
+	writeFile(t, filepath.Join(repo, ".helmver", "pr-7.md"),
+		"---\n\"api\": patch\n\"worker\": minor\n---\n\nScaled replicas\n")
+	gitRun(t, repo, "add", "-A")
+	gitRun(t, repo, "commit", "-m", "add changeset")
+
+	out, code := helmver(t, repo, "apply", "--dir", chartsDir, "--commit", "--tag")
+	if code != 0 {
+		t.Fatalf("apply failed: %d:\n%s", code, out)
+	}
+	if status := gitOutput(t, repo, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after apply --commit, got:\n%s", status)
+	}
+	if subject := gitOutput(t, repo, "log", "-1", "--format=%s"); subject != "chore: release api@1.2.4 worker@0.6.0 [skip ci]" {
+		t.Errorf("unexpected commit subject %q", subject)
+	}
+	if tags := gitOutput(t, repo, "tag", "--points-at", "HEAD"); tags != "api/v1.2.4\nworker/v0.6.0" {
+		t.Errorf("unexpected tags:\n%s", tags)
+	}
+
+	// Tags that already exist are refused before anything is written.
+	writeFile(t, filepath.Join(repo, ".helmver", "pr-8.md"),
+		"---\n\"api\": patch\n---\n\nAgain\n")
+	gitRun(t, repo, "tag", "api/v1.2.5")
+	out, code = helmver(t, repo, "apply", "--dir", chartsDir, "--commit", "--tag")
+	if code == 0 || !strings.Contains(out, "tag api/v1.2.5 already exists") {
+		t.Errorf("expected an existing tag error, got %d:\n%s", code, out)
+	}
+	if v := readFile(t, filepath.Join(chartsDir, "api", "Chart.yaml")); !strings.Contains(v, "version: 1.2.4") {
+		t.Errorf("Chart.yaml changed after refused apply:\n%s", v)
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed in %s: %v", args, dir, err)
+	}
+	return strings.TrimSpace(string(out))
+}